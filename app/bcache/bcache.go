@@ -3,13 +3,14 @@ package bcache
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/songzhibin97/go-baseutils/base/bcomparator"
 	"github.com/songzhibin97/go-baseutils/base/bmap"
 	"github.com/songzhibin97/go-baseutils/base/options"
-	"github.com/songzhibin97/go-baseutils/structure/sets/zset"
+	"github.com/songzhibin97/go-baseutils/internal/keyhash"
 )
 
 const (
@@ -21,10 +22,26 @@ var _ Cache[int, any] = (*BCache[int, any])(nil)
 
 type BCache[K comparable, V any] struct {
 	*bCache[K, V]
+
+	// bus 失效广播 未设置 SetBus 时为nil
+	bus *broadcaster[K]
 }
 
 func New[K comparable, V any](comparator bcomparator.Comparator[K], opts ...options.Option[*Config[K, V]]) *BCache[K, V] {
-	ctx, cancel := context.WithCancel(context.Background())
+	c := newConfig(comparator, opts...)
+	obj := newBCache(c)
+	if c.setSentinelFn == nil {
+		c.setSentinelFn = obj.deleteExpire
+	}
+	obj.lc = &lifecycle{}
+	obj.lc.start(c.interval, c.setSentinelFn, c.janitor, c.clock)
+	return &BCache[K, V]{
+		bCache: obj,
+		bus:    newBroadcaster[K](c.bus, c.keyCodec, c.onBusError, obj.invalidate, obj.clear),
+	}
+}
+
+func newConfig[K comparable, V any](comparator bcomparator.Comparator[K], opts ...options.Option[*Config[K, V]]) *Config[K, V] {
 	c := &Config[K, V]{
 		defaultExpire: 0,
 		interval:      0,
@@ -32,47 +49,134 @@ func New[K comparable, V any](comparator bcomparator.Comparator[K], opts ...opti
 			fmt.Printf("delete k:%v v:%v\n", k, v)
 		},
 		comparator: comparator,
+		clock:      realClock{},
+		keyCodec:   GobCodec[K]{},
+		valueCodec: GobCodec[V]{},
 	}
 	for _, option := range opts {
 		option(c)
 	}
+	return c
+}
+
+func newBCache[K comparable, V any](c *Config[K, V]) *bCache[K, V] {
 	obj := &bCache[K, V]{
 		config:        c,
 		defaultExpire: c.defaultExpire,
 		capture:       c.capture,
-		cancel:        cancel,
+		stats:         &stats{},
 	}
-	if c.setSentinelFn == nil {
-		c.setSentinelFn = obj.deleteExpire
+	obj.ctx, obj.cancel = context.WithCancel(context.Background())
+	if c.tinyLFU {
+		capacity := c.tinyLFUEntries
+		if capacity <= 0 {
+			capacity = c.maxEntries
+		}
+		if capacity <= 0 {
+			capacity = DefaultTinyLFUEntries
+		}
+		hasher := c.hasher
+		if hasher == nil {
+			hasher = keyhash.Default[K]()
+		}
+		c.newPolicy = func() Policy[K] {
+			return newTinyLFUPolicy[K](capacity, hasher)
+		}
+	}
+	if c.newPolicy == nil && (c.maxEntries > 0 || c.maxCost > 0) {
+		c.newPolicy = NewLRUPolicy[K]
+	}
+	if c.newPolicy != nil {
+		obj.policy = c.newPolicy()
 	}
 	obj.member = bmap.NewUnsafeAnyBMap[K, Iterator[V]]()
-	obj.visit = zset.New[K](comparator)
-	go NewSentinel(ctx, c.interval, c.setSentinelFn).Start()
-	return &BCache[K, V]{obj}
+	obj.visit = c.newExpiryIndex()
+	return obj
 }
 
 func (c *BCache[K, V]) Set(k K, v V, d time.Duration) {
 	c.set(k, v, d)
+	c.bus.publish(k)
 }
 
 func (c *BCache[K, V]) SetDefault(k K, v V) {
 	c.set(k, v, c.defaultExpire)
+	c.bus.publish(k)
 }
 
 func (c *BCache[K, V]) SetNoExpire(k K, v V) {
 	c.set(k, v, NoExpire)
+	c.bus.publish(k)
+}
+
+// SetSliding 设置滑动过期的缓存, 每次 Get 命中都会将过期时间顺延d
+func (c *BCache[K, V]) SetSliding(k K, v V, d time.Duration) {
+	c.setSliding(k, v, d)
+	c.bus.publish(k)
+}
+
+// SetWithTags 设置带tag的缓存, 可以通过 InvalidateTag 批量删除, 重新 Set 会覆盖原有的tag
+func (c *BCache[K, V]) SetWithTags(k K, v V, d time.Duration, tags ...string) {
+	c.setWithTags(k, v, d, tags)
+	c.bus.publish(k)
 }
 
 func (c *BCache[K, V]) SetIfAbsent(k K, v V, d time.Duration) bool {
-	return c.setIfAbsent(k, v, d)
+	ok := c.setIfAbsent(k, v, d)
+	if ok {
+		c.bus.publish(k)
+	}
+	return ok
 }
 
 func (c *BCache[K, V]) Replace(k K, v V, d time.Duration) bool {
-	return c.replace(k, v, d)
+	ok := c.replace(k, v, d)
+	if ok {
+		c.bus.publish(k)
+	}
+	return ok
 }
 
 func (c *BCache[K, V]) Delete(k K) {
 	c.bCache.Delete(k)
+	c.bus.publish(k)
+}
+
+// GetMany 在一次加锁内获取多个k, 返回未过期的kv
+func (c *BCache[K, V]) GetMany(ks []K) map[K]V {
+	return c.getMany(ks)
+}
+
+// SetMany 在一次加锁内写入多个kv, 过期时间均为d
+func (c *BCache[K, V]) SetMany(mp map[K]V, d time.Duration) {
+	c.setMany(mp, d)
+	for k := range mp {
+		c.bus.publish(k)
+	}
+}
+
+// DeleteMany 在一次加锁内删除多个k
+func (c *BCache[K, V]) DeleteMany(ks []K) {
+	c.deleteMany(ks)
+	c.bus.publish(ks...)
+}
+
+// Keys 返回所有未过期的key, 顺序不固定
+func (c *BCache[K, V]) Keys() []K {
+	return c.keys()
+}
+
+// Range 在一次加锁内复制所有未过期的kv, 之后在锁外依次回调fn, fn 返回false时停止
+// 不过期的kv expire 为零值
+func (c *BCache[K, V]) Range(fn func(k K, v V, expire time.Time) bool) {
+	c.rangeAlive(fn)
+}
+
+// InvalidateTag 在一次加锁内删除带有tag的所有kv并回调, 返回删除的数量
+func (c *BCache[K, V]) InvalidateTag(tag string) int {
+	ks := c.removeTag(tag)
+	c.bus.publish(ks...)
+	return len(ks)
 }
 
 func (c *BCache[K, V]) Get(k K) (V, bool) {
@@ -80,17 +184,71 @@ func (c *BCache[K, V]) Get(k K) (V, bool) {
 	return v, ok
 }
 
+// GetOrLoad 获取缓存, 未命中时调用loader加载并写入缓存, 相同key的并发加载只会执行一次
+func (c *BCache[K, V]) GetOrLoad(ctx context.Context, k K, loader Loader[K, V]) (V, error) {
+	return c.getOrLoad(ctx, k, loader)
+}
+
 func (c *BCache[K, V]) GetWithExpire(k K) (V, time.Time, bool) {
 	v, t, ok := c.get(k)
 	return v, t, ok
 }
 
+// Touch 将k的过期时间重置为d, k不存在返回false
+func (c *BCache[K, V]) Touch(k K, d time.Duration) bool {
+	return c.touch(k, d)
+}
+
+// TTL 获取k的剩余存活时间, 不过期返回 NoExpire, k不存在返回false
+func (c *BCache[K, V]) TTL(k K) (time.Duration, bool) {
+	return c.ttl(k)
+}
+
+// Persist 移除k的过期时间, k不存在返回false
+func (c *BCache[K, V]) Persist(k K) bool {
+	return c.persist(k)
+}
+
 func (c *BCache[K, V]) Count() int {
 	return c.count()
 }
 
+func (c *BCache[K, V]) Cost() int64 {
+	return c.cost()
+}
+
+// Stats 获取统计快照
+func (c *BCache[K, V]) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// ResetStats 重置统计
+func (c *BCache[K, V]) ResetStats() {
+	c.stats.reset()
+}
+
 func (c *BCache[K, V]) Clear() {
 	c.clear()
+	c.bus.publishClear()
+}
+
+// Close 停止哨兵协程并等待退出, 取消失效广播的订阅以及进行中的加载和后台刷新, 可以重复调用
+// 关闭后缓存仍可读写但不再周期清理过期数据
+func (c *BCache[K, V]) Close() {
+	c.lc.close()
+	c.cancel()
+	c.bus.close()
+}
+
+// SaveTo 以带版本与校验和的二进制格式流式导出到w, 导出期间分批加锁
+func (c *BCache[K, V]) SaveTo(w io.Writer) error {
+	return c.saveTo(w)
+}
+
+// LoadFrom 从 SaveTo 导出的快照加载, 校验通过后才会写入缓存
+// 校验和位于快照末尾, 加载时会先将完整的快照读入内存, 内存占用与快照大小相当
+func (c *BCache[K, V]) LoadFrom(r io.Reader) error {
+	return c.loadFrom(r)
 }
 
 func (c *BCache[K, V]) Load(data []byte) error {
@@ -101,6 +259,15 @@ func (c *BCache[K, V]) Export() ([]byte, error) {
 	return c.Marshal()
 }
 
+// entry 被移除的kv, 用于在锁外统一回调
+type entry[K comparable, V any] struct {
+	k      K
+	v      V
+	reason EvictReason
+	// expire 过期时间, 仅在 Range 中使用
+	expire time.Time
+}
+
 type bCache[K comparable, V any] struct {
 	config *Config[K, V]
 
@@ -113,147 +280,360 @@ type bCache[K comparable, V any] struct {
 	member bmap.AnyBMap[K, Iterator[V]]
 
 	// visit 维护具有超时的key
-	visit *zset.Set[K]
+	visit expiryIndex[K]
 
 	// capture 捕获删除对象时间 会返回kv值用于用户自定义处理
 	capture func(k K, v V)
 
+	// policy 淘汰策略 未设置容量上限时为nil
+	policy Policy[K]
+
+	// costs 当前缓存的总成本
+	costs int64
+
+	// stats 统计计数器
+	stats *stats
+
+	// loads 进行中的加载
+	loads group[K, V]
+	// negatives 缓存加载失败的错误
+	negatives map[K]negative
+	// loading 进行中的加载的代数, gen 为最近分配的代数
+	loading map[K]uint64
+	gen     uint64
+
+	// tagged 维护tag与key的关系
+	tagged map[string]map[K]struct{}
+
+	// lc 哨兵的生命周期 分片缓存的分片为nil
+	lc *lifecycle
+	// ctx 加载以及后台刷新使用的ctx, Close 时取消
+	ctx    context.Context
 	cancel context.CancelFunc
 
 	zero     V
 	zeroTime time.Time
 }
 
-func (c *bCache[K, V]) newIterator(v V, d time.Duration) Iterator[V] {
+func (c *bCache[K, V]) newIterator(k K, v V, d time.Duration) Iterator[V] {
+	expire, ttl := c.expireOf(d)
+	return Iterator[V]{
+		Value:  v,
+		Expire: expire,
+		cost:   c.costOf(k, v),
+		ttl:    int64(ttl),
+	}
+}
+
+// expireOf 根据d计算过期时间以及存活时间, 过期时间会加上随机抖动
+func (c *bCache[K, V]) expireOf(d time.Duration) (int64, time.Duration) {
 	var expire int64
+	var ttl time.Duration
 	switch d {
 	case NoExpire:
 	case DefaultExpire:
 		if c.defaultExpire > 0 {
-			expire = time.Now().Add(c.defaultExpire).UnixNano()
+			ttl = c.defaultExpire
+			expire = c.now() + int64(ttl)
 		}
 	default:
 		if d > 0 {
-			expire = time.Now().Add(d).UnixNano()
+			ttl = d
+			expire = c.now() + int64(ttl)
 		}
 		// 如果走到这里 默认是 NoExpire
 	}
-	return Iterator[V]{
-		Value:  v,
-		Expire: expire,
+	if expire != 0 {
+		expire += int64(c.jitter(ttl))
+	}
+	return expire, ttl
+}
+
+// costOf 计算kv的成本, 未设置成本函数时每个kv的成本为1
+func (c *bCache[K, V]) costOf(k K, v V) int64 {
+	if c.config.costFn == nil {
+		return 1
 	}
+	return c.config.costFn(k, v)
 }
 
 func (c *bCache[K, V]) set(k K, v V, d time.Duration) {
 
-	iter := c.newIterator(v, d)
+	iter := c.newIterator(k, v, d)
+	c.setIterator(k, iter)
+}
+
+func (c *bCache[K, V]) setIterator(k K, iter Iterator[V]) {
 	c.Lock()
-	defer c.Unlock()
+	evicted, _ := c.store(k, iter)
+	c.Unlock()
+
+	c.notify(evicted)
+}
+
+func (c *bCache[K, V]) setIfAbsent(k K, v V, d time.Duration) bool {
+	iter := c.newIterator(k, v, d)
 
+	c.Lock()
+	if ov, ok := c.member.Get(k); ok && !c.expired(ov, c.now()) {
+		c.Unlock()
+		return false
+	}
+	evicted, ok := c.store(k, iter)
+	c.Unlock()
+
+	c.notify(evicted)
+	return ok
+}
+
+// store 按淘汰策略腾出空间后写入kv, 返回被移除的kv以及是否写入成功, 调用方需要持有锁
+func (c *bCache[K, V]) store(k K, iter Iterator[V]) ([]entry[K, V], bool) {
+	evicted, ok := c.evict(k, iter.cost)
+	if !ok {
+		return evicted, false
+	}
+	if old, replaced := c.put(k, iter); replaced {
+		evicted = append(evicted, old)
+	}
+	return evicted, true
+}
+
+// put 写入kv并维护visit以及淘汰策略, 返回被覆盖的旧值, 调用方需要持有锁
+func (c *bCache[K, V]) put(k K, iter Iterator[V]) (entry[K, V], bool) {
 	if iter.Expire != 0 {
-		c.visit.AddB(float64(iter.Expire), k)
+		c.visit.add(k, c.deadline(iter))
+	} else {
+		c.visit.remove(k)
+	}
+	old, replaced := entry[K, V]{}, false
+	if ov, ok := c.member.Get(k); ok {
+		c.costs -= ov.cost
+		c.untag(k, ov.tags)
+		old, replaced = entry[K, V]{k: k, v: ov.Value, reason: EvictReplaced}, true
+		if c.expired(ov, c.now()) {
+			old.reason = EvictExpired
+			c.stats.expire(1)
+		}
 	}
+	c.bumpLoad(k)
 	c.member.Put(k, iter)
+	c.tag(k, iter.tags)
+	c.costs += iter.cost
+	delete(c.negatives, k)
+	c.stats.set()
+	if c.policy != nil {
+		c.policy.Add(k)
+	}
+	return old, replaced
 }
 
-func (c *bCache[K, V]) setDeadline(k K, v V, d int64) {
+// evict 写入k之前按淘汰策略腾出空间, 返回被淘汰的kv
+// 单个kv的成本超过上限时不允许写入, 同时移除k已有的旧值, 返回false
+// 调用方需要持有锁
+func (c *bCache[K, V]) evict(k K, cost int64) ([]entry[K, V], bool) {
+	if c.policy == nil {
+		return nil, true
+	}
+	var evicted []entry[K, V]
+	if c.config.maxCost > 0 && cost > c.config.maxCost {
+		if v, ok := c.delete(k); ok {
+			evicted = append(evicted, entry[K, V]{k: k, v: v, reason: EvictCapacity})
+			c.stats.evict(1)
+		}
+		return evicted, false
+	}
+	for c.overflow(k, cost) {
+		ek, ok := c.policy.Evict()
+		if !ok {
+			break
+		}
+		ev, ok := c.delete(ek)
+		if ok {
+			evicted = append(evicted, entry[K, V]{k: ek, v: ev, reason: EvictCapacity})
+			c.stats.evict(1)
+		}
+	}
+	return evicted, true
+}
 
-	if d != 0 {
-		c.visit.AddB(float64(d), k)
+// overflow 判断写入k后是否超出条目数或成本上限
+func (c *bCache[K, V]) overflow(k K, cost int64) bool {
+	ov, exist := c.member.Get(k)
+	if c.config.maxEntries > 0 && !exist && c.member.Size() >= c.config.maxEntries {
+		return true
 	}
-	c.member.Put(k, Iterator[V]{
-		Value:  v,
-		Expire: d,
-	})
+	if c.config.maxCost > 0 {
+		if exist {
+			cost -= ov.cost
+		}
+		return c.costs+cost > c.config.maxCost
+	}
+	return false
 }
 
-func (c *bCache[K, V]) setIfAbsent(k K, v V, d time.Duration) bool {
-	iter := c.newIterator(v, d)
+// notify 在锁外回调被移除的kv
+// onEvict 接收所有原因的移除, capture 只接收主动删除以及超出容量的淘汰
+func (c *bCache[K, V]) notify(entries []entry[K, V]) {
+	for _, e := range entries {
+		if c.config.onEvict != nil {
+			c.config.onEvict(e.k, e.v, e.reason)
+		}
+		if c.capture != nil && (e.reason == EvictDeleted || e.reason == EvictCapacity) {
+			c.capture(e.k, e.v)
+		}
+	}
+}
 
+func (c *bCache[K, V]) get(k K) (V, time.Time, bool) {
 	c.Lock()
-	defer c.Unlock()
+	now := c.now()
+	v, removed, ok := c.lookup(k, now)
+	c.Unlock()
 
-	ok := c.member.PuTIfAbsent(k, iter)
-	if ok && iter.Expire != 0 {
-		c.visit.AddB(float64(iter.Expire), k)
+	if !ok {
+		c.notify(removed)
+		return c.zero, c.zeroTime, false
 	}
-	return ok
+	if c.needRefresh(v, now) {
+		c.refresh(k)
+	}
+	return v.Value, v.expireTime(), true
 }
 
-func (c *bCache[K, V]) get(k K) (V, time.Time, bool) {
-	c.Lock()
-	defer c.Unlock()
+// lookup 获取未过期的k并记录统计, 维护淘汰策略以及滑动过期
+// k已过期时删除k并通过removed返回, 调用方需要持有锁
+func (c *bCache[K, V]) lookup(k K, now int64) (Iterator[V], []entry[K, V], bool) {
 	v, ok := c.member.Get(k)
 	if !ok {
-		return c.zero, c.zeroTime, false
+		c.stats.miss()
+		return v, nil, false
 	}
-	if v.expired() {
+	if c.expired(v, now) {
 		c.delete(k)
-		return c.zero, c.zeroTime, false
+		c.stats.expire(1)
+		c.stats.miss()
+		return v, []entry[K, V]{{k: k, v: v.Value, reason: EvictExpired}}, false
+	}
+	c.stats.hit()
+	if c.policy != nil {
+		c.policy.Access(k)
 	}
-	if !v.isVisit() {
-		return v.Value, c.zeroTime, true
+	if v.sliding && v.ttl > 0 {
+		v.Expire = now + v.ttl
+		c.resetExpire(k, v)
 	}
-	return v.Value, time.Unix(0, v.Expire), true
+	return v, nil, true
 }
 
 func (c *bCache[K, V]) replace(k K, v V, d time.Duration) bool {
-	iter := c.newIterator(v, d)
+	iter := c.newIterator(k, v, d)
 	c.Lock()
-	defer c.Unlock()
 	ov, ok := c.member.Get(k)
 	if !ok {
+		c.Unlock()
 		return false
 	}
-	if ov.expired() {
+	if c.expired(ov, c.now()) {
 		c.delete(k)
+		c.Unlock()
+		c.stats.expire(1)
+		c.notify([]entry[K, V]{{k: k, v: ov.Value, reason: EvictExpired}})
 		return false
 	}
-	c.member.Put(k, iter)
-	if iter.Expire != 0 {
-		c.visit.AddB(float64(iter.Expire), k)
-	}
-	return true
+	evicted, ok := c.store(k, iter)
+	c.Unlock()
+
+	c.notify(evicted)
+	return ok
 }
 
 func (c *bCache[K, V]) Delete(k K) {
 	c.Lock()
-	defer c.Unlock()
+	c.bumpLoad(k)
 	v, ok := c.delete(k)
-	if ok && c.capture != nil {
-		c.capture(k, v)
+	c.Unlock()
+	if !ok {
+		return
 	}
+	c.stats.delete()
+	c.notify([]entry[K, V]{{k: k, v: v, reason: EvictDeleted}})
 }
 
 func (c *bCache[K, V]) delete(k K) (V, bool) {
+	delete(c.negatives, k)
 	nv, ok := c.member.DeleteIfPresent(k)
 	if !ok {
 		return c.zero, false
 	}
-	c.visit.Remove(k)
+	c.visit.remove(k)
+	c.untag(k, nv.tags)
+	c.costs -= nv.cost
+	if c.policy != nil {
+		c.policy.Remove(k)
+	}
 	return nv.Value, true
 }
 
 func (c *bCache[K, V]) deleteExpire() {
+	var expired []entry[K, V]
 	c.Lock()
-	defer c.Unlock()
-	nodes := c.visit.RemoveRangeByScore(0, float64(time.Now().UnixNano()))
-	for _, n := range nodes {
-		c.member.Delete(n.Value)
+	now := c.now()
+	c.deleteNegativeExpire(now)
+	keys := c.visit.expire(now, c.config.maxExpirePerTick)
+	c.stats.expire(len(keys))
+	for _, k := range keys {
+		if v, ok := c.member.DeleteIfPresent(k); ok {
+			c.costs -= v.cost
+			c.untag(k, v.tags)
+			if c.config.onEvict != nil {
+				expired = append(expired, entry[K, V]{k: k, v: v.Value, reason: EvictExpired})
+			}
+		}
+		if c.policy != nil {
+			c.policy.Remove(k)
+		}
 	}
+	c.Unlock()
+
+	c.notify(expired)
 }
 
 func (c *bCache[K, V]) count() int {
-	c.Lock()
-	defer c.Unlock()
+	c.RLock()
+	defer c.RUnlock()
 	return c.member.Size()
 }
 
+func (c *bCache[K, V]) cost() int64 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.costs
+}
+
 func (c *bCache[K, V]) clear() {
 	c.Lock()
-	defer c.Unlock()
+	old := c.member
+	c.costs = 0
+	c.negatives = nil
+	c.tagged = nil
+	for k := range c.loading {
+		c.bumpLoad(k)
+	}
 	c.member = bmap.NewUnsafeAnyBMap[K, Iterator[V]]()
-	c.visit = zset.New[K](c.config.comparator)
+	c.visit.clear()
+	if c.policy != nil {
+		c.policy.Clear()
+	}
+	c.Unlock()
+
+	if c.config.onEvict == nil {
+		return
+	}
+	cleared := make([]entry[K, V], 0, old.Size())
+	old.ForEach(func(k K, v Iterator[V]) {
+		cleared = append(cleared, entry[K, V]{k: k, v: v.Value, reason: EvictCleared})
+	})
+	c.notify(cleared)
 }
 
 func (c *bCache[K, V]) Marshal() ([]byte, error) {
@@ -263,17 +643,35 @@ func (c *bCache[K, V]) Marshal() ([]byte, error) {
 }
 
 func (c *bCache[K, V]) Unmarshal(data []byte) error {
-	c.Lock()
-	defer c.Unlock()
 	mp := bmap.NewUnsafeAnyBMap[K, Iterator[V]]()
 	err := mp.Unmarshal(data)
 	if err != nil {
 		return err
 	}
-	mp.ForEach(func(k K, v Iterator[V]) {
-		if !v.expired() {
-			c.setDeadline(k, v.Value, v.Expire)
-		}
-	})
+	c.load(mp.ToMetaMap())
 	return nil
 }
+
+// snapshot 复制当前所有kv
+func (c *bCache[K, V]) snapshot() map[K]Iterator[V] {
+	c.RLock()
+	defer c.RUnlock()
+	return c.member.CloneToMap()
+}
+
+// load 写入未过期的kv
+func (c *bCache[K, V]) load(mp map[K]Iterator[V]) {
+	var evicted []entry[K, V]
+	c.Lock()
+	now := c.now()
+	for k, v := range mp {
+		if !c.expired(v, now) {
+			v.cost = c.costOf(k, v.Value)
+			ev, _ := c.store(k, v)
+			evicted = append(evicted, ev...)
+		}
+	}
+	c.Unlock()
+
+	c.notify(evicted)
+}
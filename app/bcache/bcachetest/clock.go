@@ -0,0 +1,133 @@
+// Package bcachetest 提供 bcache 的测试辅助工具
+package bcachetest
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/app/bcache"
+)
+
+var (
+	_ bcache.Clock         = (*FakeClock)(nil)
+	_ bcache.HandledTicker = (*fakeTicker)(nil)
+)
+
+// FakeClock 手动推进的时钟, 只有调用 Advance 时时间才会前进并触发到期的 Ticker
+type FakeClock struct {
+	sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock 初始化手动时钟, now 为初始时间
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.Lock()
+	defer f.Unlock()
+	return f.now
+}
+
+func (f *FakeClock) NewTicker(d time.Duration) bcache.Ticker {
+	if d <= 0 {
+		panic("bcachetest: non-positive interval for NewTicker")
+	}
+	f.Lock()
+	defer f.Unlock()
+	t := &fakeTicker{
+		c:       make(chan time.Time),
+		handled: make(chan struct{}),
+		stop:    make(chan struct{}),
+		period:  d,
+		next:    f.now.Add(d),
+	}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance 时间前进d, 并依次触发到期的 Ticker
+// 与 time.Ticker 相同, 一次推进跨越多个周期也只会触发一次
+// Advance 会阻塞到触发被接收为止, 接收方是缓存或 Janitor 的哨兵时会阻塞到触发处理完成为止,
+// 所以 Advance 返回后可以直接断言清理的结果
+func (f *FakeClock) Advance(d time.Duration) {
+	f.Lock()
+	f.now = f.now.Add(d)
+	now := f.now
+	var due []*fakeTicker
+	alive := f.tickers[:0]
+	for _, t := range f.tickers {
+		if t.stopped() {
+			continue
+		}
+		alive = append(alive, t)
+		if !t.next.After(now) {
+			due = append(due, t)
+			for !t.next.After(now) {
+				t.next = t.next.Add(t.period)
+			}
+		}
+	}
+	f.tickers = alive
+	f.Unlock()
+
+	for _, t := range due {
+		select {
+		case t.c <- now:
+		case <-t.stop:
+			continue
+		}
+		if atomic.LoadInt32(&t.acking) == 1 {
+			select {
+			case <-t.handled:
+			case <-t.stop:
+			}
+		}
+	}
+}
+
+type fakeTicker struct {
+	c chan time.Time
+	// handled 接收方处理完一次触发
+	handled chan struct{}
+	// acking 接收方是否会通知处理完成
+	acking int32
+	stop   chan struct{}
+	once   sync.Once
+	period time.Duration
+	// next 下一次触发的时间, 由 FakeClock 的锁保护
+	next time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+// Handled 第一次调用表示接收方会通知处理完成, 之后每次调用通知 Advance 上一次触发已经处理完成
+func (t *fakeTicker) Handled() {
+	if atomic.CompareAndSwapInt32(&t.acking, 0, 1) {
+		return
+	}
+	select {
+	case t.handled <- struct{}{}:
+	case <-t.stop:
+	}
+}
+
+func (t *fakeTicker) Stop() {
+	t.once.Do(func() {
+		close(t.stop)
+	})
+}
+
+func (t *fakeTicker) stopped() bool {
+	select {
+	case <-t.stop:
+		return true
+	default:
+		return false
+	}
+}
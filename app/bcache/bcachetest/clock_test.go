@@ -0,0 +1,178 @@
+package bcachetest
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/app/bcache"
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+	"github.com/songzhibin97/go-baseutils/base/options"
+)
+
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+	ticker := clock.NewTicker(time.Second)
+
+	received := make(chan time.Time, 1)
+	go func() {
+		for tm := range ticker.C() {
+			received <- tm
+		}
+	}()
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, start.Add(500*time.Millisecond), clock.Now())
+	select {
+	case <-received:
+		t.Fatal("ticker fired before its period")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, start.Add(time.Second), <-received)
+
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, start.Add(4*time.Second), <-received)
+
+	ticker.Stop()
+	clock.Advance(time.Second)
+}
+
+func TestFakeClockCache(t *testing.T) {
+	indexes := map[string][]options.Option[*bcache.Config[string, int]]{
+		"zset":  nil,
+		"wheel": {bcache.SetTimingWheel[string, int](100 * time.Millisecond)},
+	}
+	for name, opts := range indexes {
+		t.Run(name, func(t *testing.T) {
+			testFakeClockCache(t, opts...)
+		})
+	}
+}
+
+func testFakeClockCache(t *testing.T, opts ...options.Option[*bcache.Config[string, int]]) {
+	clock := NewFakeClock(time.Now())
+	opts = append(opts,
+		bcache.SetClock[string, int](clock),
+		bcache.SetInternal[string, int](time.Second),
+		bcache.SetCapture[string, int](nil),
+	)
+	tc := bcache.New[string, int](bcomparator.StringComparator(), opts...)
+	defer tc.Close()
+
+	tc.Set("a", 1, 5*time.Second)
+	tc.Set("b", 2, 10*time.Second)
+	tc.Set("c", 3, bcache.NoExpire)
+
+	clock.Advance(4 * time.Second)
+	_, ok := tc.Get("a")
+	assert.True(t, ok)
+	d, _ := tc.TTL("a")
+	assert.Equal(t, time.Second, d)
+
+	clock.Advance(2 * time.Second)
+	_, ok = tc.Get("a")
+	assert.False(t, ok)
+
+	// Advance 返回时哨兵的清理已经完成
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, 1, tc.Count())
+	_, ok = tc.Get("c")
+	assert.True(t, ok)
+}
+
+func TestFakeClockOnEvict(t *testing.T) {
+	type event struct {
+		k      string
+		reason bcache.EvictReason
+	}
+	var (
+		mu     sync.Mutex
+		events []event
+	)
+	clock := NewFakeClock(time.Now())
+	tc := bcache.New[string, int](bcomparator.StringComparator(),
+		bcache.SetClock[string, int](clock),
+		bcache.SetInternal[string, int](time.Second),
+		bcache.SetCapture[string, int](nil),
+		bcache.SetOnEvict[string, int](func(k string, v int, reason bcache.EvictReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event{k, reason})
+		}),
+	)
+	defer tc.Close()
+
+	tc.Set("a", 1, 500*time.Millisecond)
+	tc.Set("b", 1, 1500*time.Millisecond)
+	clock.Advance(600 * time.Millisecond)
+	// 读取时惰性删除
+	tc.Get("a")
+	// 哨兵周期删除, Advance 返回时清理已经完成
+	clock.Advance(time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []event{{"a", bcache.EvictExpired}, {"b", bcache.EvictExpired}}, events)
+}
+
+func TestFakeClockMaxExpirePerTick(t *testing.T) {
+	indexes := map[string][]options.Option[*bcache.Config[string, int]]{
+		"zset":  nil,
+		"wheel": {bcache.SetTimingWheel[string, int](100 * time.Millisecond)},
+	}
+	for name, opts := range indexes {
+		t.Run(name, func(t *testing.T) {
+			clock := NewFakeClock(time.Now())
+			opts = append(opts,
+				bcache.SetClock[string, int](clock),
+				bcache.SetInternal[string, int](time.Second),
+				bcache.SetMaxExpirePerTick[string, int](3),
+				bcache.SetCapture[string, int](nil),
+			)
+			tc := bcache.New[string, int](bcomparator.StringComparator(), opts...)
+			defer tc.Close()
+			for i := 0; i < 10; i++ {
+				tc.Set(strconv.Itoa(i), i, 500*time.Millisecond)
+			}
+
+			// 每次最多清理3个, 共需要4次
+			for _, n := range []int{7, 4, 1, 0} {
+				clock.Advance(time.Second)
+				assert.Equal(t, n, tc.Count())
+			}
+			assert.Equal(t, uint64(10), tc.Stats().Expirations)
+		})
+	}
+}
+
+func TestFakeClockJanitor(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	j := bcache.NewJanitor(time.Second, bcache.SetJanitorClock(clock))
+	defer j.Close()
+
+	a := bcache.New[string, int](bcomparator.StringComparator(),
+		bcache.SetJanitor[string, int](j), bcache.SetClock[string, int](clock))
+	b := bcache.NewSharded[string, int](bcomparator.StringComparator(), 4,
+		bcache.SetJanitor[string, int](j), bcache.SetClock[string, int](clock))
+	a.Set("a", 1, 500*time.Millisecond)
+	b.Set("b", 1, 500*time.Millisecond)
+	clock.Advance(time.Second)
+	assert.Equal(t, 0, a.Count())
+	assert.Equal(t, 0, b.Count())
+
+	// 注销后不再清理
+	a.Close()
+	b.Close()
+	a.Set("a", 1, 500*time.Millisecond)
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, a.Count())
+
+	j.Close()
+	j.Close()
+}
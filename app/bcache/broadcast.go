@@ -0,0 +1,88 @@
+package bcache
+
+// broadcaster 通过 Bus 发布本地的写入, 并应用其他缓存发布的失效消息
+type broadcaster[K comparable] struct {
+	bus         Bus
+	source      string
+	codec       Codec[K]
+	onError     func(err error)
+	unsubscribe func()
+}
+
+// newBroadcaster 订阅bus, 收到其他缓存的消息后调用invalidate或clear, bus 为nil时返回nil
+// 订阅失败时通过onError返回错误, 之后仍然会发布本地的写入
+func newBroadcaster[K comparable](bus Bus, codec Codec[K], onError func(err error), invalidate func(k K), clear func()) *broadcaster[K] {
+	if bus == nil {
+		return nil
+	}
+	b := &broadcaster[K]{
+		bus:     bus,
+		source:  newSource(),
+		codec:   codec,
+		onError: onError,
+	}
+	var err error
+	b.unsubscribe, err = bus.Subscribe(b.source, func(msg Invalidation) {
+		// 自定义的 Bus 可能不会过滤自己发布的消息
+		if msg.Source == b.source {
+			return
+		}
+		if msg.Clear {
+			clear()
+			return
+		}
+		if k, err := b.codec.Decode(msg.Key); err == nil {
+			invalidate(k)
+		}
+	})
+	b.report(err)
+	return b
+}
+
+// report 通过onError返回错误, 未设置时忽略
+func (b *broadcaster[K]) report(err error) {
+	if err != nil && b.onError != nil {
+		b.onError(err)
+	}
+}
+
+// publish 发布k的失效消息, 编码或发布失败时通过onError返回错误
+func (b *broadcaster[K]) publish(ks ...K) {
+	if b == nil {
+		return
+	}
+	for _, k := range ks {
+		data, err := b.codec.Encode(k)
+		if err != nil {
+			b.report(err)
+			continue
+		}
+		b.report(b.bus.Publish(Invalidation{Source: b.source, Key: data}))
+	}
+}
+
+func (b *broadcaster[K]) publishClear() {
+	if b == nil {
+		return
+	}
+	b.report(b.bus.Publish(Invalidation{Source: b.source, Clear: true}))
+}
+
+// close 取消订阅
+func (b *broadcaster[K]) close() {
+	if b == nil || b.unsubscribe == nil {
+		return
+	}
+	b.unsubscribe()
+}
+
+// invalidate 应用其他缓存发布的失效消息, 不记录统计
+func (c *bCache[K, V]) invalidate(k K) {
+	c.Lock()
+	c.bumpLoad(k)
+	v, ok := c.delete(k)
+	c.Unlock()
+	if ok {
+		c.notify([]entry[K, V]{{k: k, v: v, reason: EvictInvalidated}})
+	}
+}
@@ -0,0 +1,96 @@
+package bcache
+
+import "time"
+
+func (c *bCache[K, V]) getMany(ks []K) map[K]V {
+	ret := make(map[K]V, len(ks))
+	var removed []entry[K, V]
+	var refresh []K
+	c.Lock()
+	now := c.now()
+	for _, k := range ks {
+		v, ev, ok := c.lookup(k, now)
+		if !ok {
+			removed = append(removed, ev...)
+			continue
+		}
+		ret[k] = v.Value
+		if c.needRefresh(v, now) {
+			refresh = append(refresh, k)
+		}
+	}
+	c.Unlock()
+
+	c.notify(removed)
+	for _, k := range refresh {
+		c.refresh(k)
+	}
+	return ret
+}
+
+func (c *bCache[K, V]) setMany(mp map[K]V, d time.Duration) {
+	var evicted []entry[K, V]
+	c.Lock()
+	for k, v := range mp {
+		ev, _ := c.store(k, c.newIterator(k, v, d))
+		evicted = append(evicted, ev...)
+	}
+	c.Unlock()
+
+	c.notify(evicted)
+}
+
+func (c *bCache[K, V]) deleteMany(ks []K) {
+	var removed []entry[K, V]
+	c.Lock()
+	for _, k := range ks {
+		c.bumpLoad(k)
+		if v, ok := c.delete(k); ok {
+			removed = append(removed, entry[K, V]{k: k, v: v, reason: EvictDeleted})
+		}
+	}
+	c.Unlock()
+
+	for range removed {
+		c.stats.delete()
+	}
+	c.notify(removed)
+}
+
+// keys 返回所有未过期的key
+func (c *bCache[K, V]) keys() []K {
+	c.RLock()
+	defer c.RUnlock()
+	ret := make([]K, 0, c.member.Size())
+	now := c.now()
+	c.member.ForEach(func(k K, v Iterator[V]) {
+		if !c.expired(v, now) {
+			ret = append(ret, k)
+		}
+	})
+	return ret
+}
+
+// alives 复制所有未过期的kv
+func (c *bCache[K, V]) alives() []entry[K, V] {
+	c.RLock()
+	defer c.RUnlock()
+	ret := make([]entry[K, V], 0, c.member.Size())
+	now := c.now()
+	c.member.ForEach(func(k K, v Iterator[V]) {
+		if !c.expired(v, now) {
+			ret = append(ret, entry[K, V]{k: k, v: v.Value, expire: v.expireTime()})
+		}
+	})
+	return ret
+}
+
+// rangeAlive 在锁外依次回调未过期的kv, fn 返回false时停止并返回false
+func (c *bCache[K, V]) rangeAlive(fn func(k K, v V, expire time.Time) bool) bool {
+	for _, e := range c.alives() {
+		if !fn(e.k, e.v, e.expire) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package bcache
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func testBulk(t *testing.T, tc Cache[int, int]) {
+	tc.SetMany(map[int]int{1: 1, 2: 2, 3: 3, 4: 4}, NoExpire)
+	tc.Set(5, 5, time.Millisecond)
+	tc.Set(6, 6, time.Hour)
+	<-time.After(2 * time.Millisecond)
+
+	assert.Equal(t, map[int]int{1: 1, 3: 3, 6: 6}, tc.GetMany([]int{1, 3, 5, 6, 7}))
+
+	keys := tc.Keys()
+	sort.Ints(keys)
+	assert.Equal(t, []int{1, 2, 3, 4, 6}, keys)
+
+	got := make(map[int]int)
+	tc.Range(func(k int, v int, expire time.Time) bool {
+		got[k] = v
+		if k == 6 {
+			assert.False(t, expire.IsZero())
+		} else {
+			assert.True(t, expire.IsZero())
+		}
+		return true
+	})
+	assert.Equal(t, map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 6: 6}, got)
+
+	n := 0
+	tc.Range(func(k int, v int, expire time.Time) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 1, n)
+
+	tc.DeleteMany([]int{1, 2, 7})
+	keys = tc.Keys()
+	sort.Ints(keys)
+	assert.Equal(t, []int{3, 4, 6}, keys)
+	assert.Equal(t, uint64(2), tc.Stats().Deletes)
+}
+
+func TestBulk(t *testing.T) {
+	testBulk(t, New[int, int](bcomparator.IntComparator(), SetCapture[int, int](nil)))
+}
+
+func TestShardedBulk(t *testing.T) {
+	testBulk(t, NewSharded[int, int](bcomparator.IntComparator(), 4, SetCapture[int, int](nil)))
+}
+
+func TestRangeReentrant(t *testing.T) {
+	tc := New[int, int](bcomparator.IntComparator(), SetCapture[int, int](nil))
+	tc.SetMany(map[int]int{1: 1, 2: 2}, NoExpire)
+	tc.Range(func(k int, v int, expire time.Time) bool {
+		tc.Delete(k)
+		return true
+	})
+	assert.Equal(t, 0, tc.Count())
+}
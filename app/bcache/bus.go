@@ -0,0 +1,215 @@
+package bcache
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+var _ Bus = (*ChannelBus)(nil)
+
+var (
+	ErrBusClosed  = errors.New("bcache: bus closed")
+	ErrBusCorrupt = errors.New("bcache: corrupt invalidation message")
+)
+
+// Invalidation 失效消息
+type Invalidation struct {
+	// Source 发布者的标识, 缓存会忽略自己发布的消息
+	Source string
+	// Key 使用 Config.keyCodec 编码后的key
+	Key []byte
+	// Clear 清空缓存, 为true时忽略Key
+	Clear bool
+}
+
+// Bus 失效广播, 缓存写入或删除key时发布失效消息, 其他订阅的缓存收到后删除本地的key
+type Bus interface {
+	// Publish 发布消息, 不会投递给 source 与 msg.Source 相同的订阅, 实现不能阻塞在订阅的回调上
+	Publish(msg Invalidation) error
+	// Subscribe 订阅消息, source 为订阅者的标识, 为空时接收所有消息
+	// fn 在单独的协程中依次回调, 可以在回调中发布消息, 返回取消订阅的函数
+	Subscribe(source string, fn func(msg Invalidation)) (func(), error)
+}
+
+// marshal 编码消息: uvarint(len(source)) source flags(1) key
+func (m Invalidation) marshal() []byte {
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(m.Source)+1+len(m.Key))
+	n := binary.PutUvarint(buf, uint64(len(m.Source)))
+	buf = append(buf[:n], m.Source...)
+	var flags byte
+	if m.Clear {
+		flags = 1
+	}
+	buf = append(buf, flags)
+	return append(buf, m.Key...)
+}
+
+func (m *Invalidation) unmarshal(data []byte) error {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < size+1 {
+		return ErrBusCorrupt
+	}
+	data = data[n:]
+	m.Source = string(data[:size])
+	m.Clear = data[size]&1 != 0
+	m.Key = append([]byte(nil), data[size+1:]...)
+	return nil
+}
+
+// newSource 生成随机的发布者标识
+func newSource() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// =====================================================================================================================
+// channel
+
+// channelSub 订阅的无界队列, 发布时只入队不阻塞, 回调在订阅自己的协程中执行
+type channelSub struct {
+	source string
+
+	mu     sync.Mutex // Protection queue&closed
+	queue  []Invalidation
+	closed bool
+
+	// notify 队列有新消息或关闭时写入
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func (s *channelSub) push(msg Invalidation) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+	s.signal()
+}
+
+// close 关闭队列, 已经入队的消息仍会被回调
+func (s *channelSub) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *channelSub) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run 依次回调队列中的消息, 队列关闭并处理完后退出
+func (s *channelSub) run(fn func(msg Invalidation)) {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		queue, closed := s.queue, s.closed
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, msg := range queue {
+			fn(msg)
+		}
+		if len(queue) != 0 {
+			continue
+		}
+		if closed {
+			return
+		}
+		<-s.notify
+	}
+}
+
+// ChannelBus 进程内的失效广播, 每个订阅持有一个无界队列, Publish 不会阻塞
+type ChannelBus struct {
+	sync.RWMutex
+	subs   map[uint64]*channelSub
+	next   uint64
+	buffer int
+	closed bool
+}
+
+// NewChannelBus 初始化进程内的失效广播, buffer 为每个订阅队列的初始容量, 队列不限制长度
+func NewChannelBus(buffer int) *ChannelBus {
+	return &ChannelBus{
+		subs:   make(map[uint64]*channelSub),
+		buffer: buffer,
+	}
+}
+
+func (b *ChannelBus) Publish(msg Invalidation) error {
+	b.RLock()
+	defer b.RUnlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+	for _, sub := range b.subs {
+		if sub.source != "" && sub.source == msg.Source {
+			continue
+		}
+		sub.push(msg)
+	}
+	return nil
+}
+
+func (b *ChannelBus) Subscribe(source string, fn func(msg Invalidation)) (func(), error) {
+	b.Lock()
+	defer b.Unlock()
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+	id := b.next
+	b.next++
+	sub := &channelSub{
+		source: source,
+		queue:  make([]Invalidation, 0, b.buffer),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	b.subs[id] = sub
+	go sub.run(fn)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.Lock()
+			if _, ok := b.subs[id]; ok {
+				delete(b.subs, id)
+				sub.close()
+			}
+			b.Unlock()
+			<-sub.done
+		})
+	}, nil
+}
+
+// Close 关闭广播, 等待所有订阅处理完已经发布的消息
+func (b *ChannelBus) Close() error {
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return nil
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = nil
+	for _, sub := range subs {
+		sub.close()
+	}
+	b.Unlock()
+
+	for _, sub := range subs {
+		<-sub.done
+	}
+	return nil
+}
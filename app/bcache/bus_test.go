@@ -0,0 +1,207 @@
+package bcache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestInvalidationMarshal(t *testing.T) {
+	msg := Invalidation{Source: "a", Key: []byte("key")}
+	var got Invalidation
+	assert.NoError(t, got.unmarshal(msg.marshal()))
+	assert.Equal(t, msg, got)
+
+	msg = Invalidation{Source: "b", Clear: true}
+	got = Invalidation{}
+	assert.NoError(t, got.unmarshal(msg.marshal()))
+	assert.Equal(t, "b", got.Source)
+	assert.True(t, got.Clear)
+	assert.Empty(t, got.Key)
+
+	assert.ErrorIs(t, got.unmarshal([]byte{5, 'a'}), ErrBusCorrupt)
+}
+
+func TestChannelBus(t *testing.T) {
+	bus := NewChannelBus(8)
+	var mu sync.Mutex
+	var got []string
+	unsubscribe, err := bus.Subscribe("", func(msg Invalidation) {
+		mu.Lock()
+		got = append(got, string(msg.Key))
+		mu.Unlock()
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, bus.Publish(Invalidation{Key: []byte("a")}))
+	assert.NoError(t, bus.Publish(Invalidation{Key: []byte("b")}))
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, []string{"a", "b"}, got)
+
+	assert.NoError(t, bus.Publish(Invalidation{Key: []byte("c")}))
+	assert.Len(t, got, 2)
+
+	assert.NoError(t, bus.Close())
+	assert.ErrorIs(t, bus.Publish(Invalidation{}), ErrBusClosed)
+	_, err = bus.Subscribe("", func(msg Invalidation) {})
+	assert.ErrorIs(t, err, ErrBusClosed)
+}
+
+func TestChannelBusSource(t *testing.T) {
+	bus := NewChannelBus(0)
+	defer bus.Close()
+	var mu sync.Mutex
+	var got []string
+	_, err := bus.Subscribe("a", func(msg Invalidation) {
+		mu.Lock()
+		got = append(got, msg.Source)
+		mu.Unlock()
+	})
+	assert.NoError(t, err)
+	// 不会投递给发布者自己
+	assert.NoError(t, bus.Publish(Invalidation{Source: "a"}))
+	assert.NoError(t, bus.Publish(Invalidation{Source: "b"}))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 1
+	}, time.Second, time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"b"}, got)
+	mu.Unlock()
+}
+
+func TestChannelBusReentrant(t *testing.T) {
+	bus := NewChannelBus(0)
+	defer bus.Close()
+
+	var a *BCache[string, int]
+	a = New[string, int](bcomparator.StringComparator(),
+		SetCapture[string, int](nil),
+		SetBus[string, int](bus),
+		SetOnEvict[string, int](func(k string, v int, reason EvictReason) {
+			// 回调中写回同一个缓存会再次发布消息
+			if reason == EvictInvalidated {
+				a.Set(k+"-evicted", v, NoExpire)
+			}
+		}),
+	)
+	defer a.Close()
+	b := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil), SetBus[string, int](bus))
+	defer b.Close()
+
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		return 1, NoExpire, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			k := strconv.Itoa(i)
+			_, _ = a.GetOrLoad(context.Background(), k, loader)
+			b.Set(k, i, NoExpire)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish blocked by re-entrant subscriber")
+	}
+	assert.Eventually(t, func() bool {
+		_, ok := a.Get("99-evicted")
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestBusError(t *testing.T) {
+	bus := NewChannelBus(0)
+	assert.NoError(t, bus.Close())
+
+	var errs []error
+	onError := SetBusErrorHandler[string, int](func(err error) {
+		errs = append(errs, err)
+	})
+	a := New[string, int](bcomparator.StringComparator(), SetBus[string, int](bus), onError)
+	defer a.Close()
+	b := NewSharded[string, int](bcomparator.StringComparator(), 4, SetBus[string, int](bus), onError)
+	defer b.Close()
+	// 两次订阅失败
+	assert.Len(t, errs, 2)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrBusClosed)
+	}
+
+	a.Set("a", 1, NoExpire)
+	b.Clear()
+	assert.Len(t, errs, 4)
+	assert.ErrorIs(t, errs[3], ErrBusClosed)
+}
+
+// testBusCache 两个缓存共享广播, 一个缓存写入或删除后另一个缓存中的key失效
+func testBusCache(t *testing.T, a, b Cache[string, int]) {
+	// GetOrLoad 写入不会发布失效消息
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		return 1, NoExpire, nil
+	}
+	for _, k := range []string{"a", "b"} {
+		_, _ = a.GetOrLoad(context.Background(), k, loader)
+		_, _ = b.GetOrLoad(context.Background(), k, loader)
+	}
+
+	b.Set("a", 2, NoExpire)
+	assert.Eventually(t, func() bool {
+		_, ok := a.Get("a")
+		return !ok
+	}, time.Second, time.Millisecond)
+	v, ok := b.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	a.Delete("b")
+	assert.Eventually(t, func() bool {
+		_, ok := b.Get("b")
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	_, _ = a.GetOrLoad(context.Background(), "c", loader)
+	b.Clear()
+	assert.Eventually(t, func() bool {
+		return a.Count() == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestBusCache(t *testing.T) {
+	bus := NewChannelBus(16)
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var reasons []EvictReason
+	onEvict := SetOnEvict[string, int](func(k string, v int, reason EvictReason) {
+		mu.Lock()
+		reasons = append(reasons, reason)
+		mu.Unlock()
+	})
+	a := New[string, int](bcomparator.StringComparator(), SetBus[string, int](bus), SetCapture[string, int](nil), onEvict)
+	b := NewSharded[string, int](bcomparator.StringComparator(), 4, SetBus[string, int](bus), SetCapture[string, int](nil))
+	defer a.Close()
+	defer b.Close()
+	testBusCache(t, a, b)
+
+	mu.Lock()
+	assert.Contains(t, reasons, EvictInvalidated)
+	mu.Unlock()
+
+	// 关闭后不再接收失效消息
+	a.Close()
+	a.Set("d", 4, NoExpire)
+	b.Delete("d")
+	<-time.After(10 * time.Millisecond)
+	_, ok := a.Get("d")
+	assert.True(t, ok)
+}
@@ -1,18 +1,40 @@
 package bcache
 
-import "time"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type Cache[K comparable, V any] interface {
 	Set(k K, v V, d time.Duration)
 	SetDefault(k K, v V)
 	SetNoExpire(k K, v V)
+	SetSliding(k K, v V, d time.Duration)
+	SetMany(mp map[K]V, d time.Duration)
+	SetWithTags(k K, v V, d time.Duration, tags ...string)
 	SetIfAbsent(k K, v V, d time.Duration) bool
 	Replace(k K, v V, d time.Duration) bool
 	Delete(k K)
+	DeleteMany(ks []K)
+	InvalidateTag(tag string) int
 	Get(k K) (V, bool)
 	GetWithExpire(k K) (V, time.Time, bool)
+	GetMany(ks []K) map[K]V
+	Keys() []K
+	Range(fn func(k K, v V, expire time.Time) bool)
+	GetOrLoad(ctx context.Context, k K, loader Loader[K, V]) (V, error)
+	Touch(k K, d time.Duration) bool
+	TTL(k K) (time.Duration, bool)
+	Persist(k K) bool
 	Count() int
+	Cost() int64
+	Stats() Stats
+	ResetStats()
 	Clear()
+	Close()
+	SaveTo(w io.Writer) error
+	LoadFrom(r io.Reader) error
 	Load(data []byte) error
 	Export() ([]byte, error)
 	Marshal() ([]byte, error)
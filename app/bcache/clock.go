@@ -0,0 +1,53 @@
+package bcache
+
+import "time"
+
+// Clock 时钟, 缓存通过 Clock 获取当前时间以及驱动哨兵, 测试时可以替换为手动推进的时钟
+type Clock interface {
+	// Now 当前时间
+	Now() time.Time
+	// NewTicker 创建周期为d的 Ticker
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker 周期触发器
+type Ticker interface {
+	// C 触发时间的channel
+	C() <-chan time.Time
+	// Stop 停止触发
+	Stop()
+}
+
+// HandledTicker 可选接口, 哨兵开始接收触发前调用一次 Handled, 之后每处理完一次触发调用一次
+// 手动推进的时钟可以据此等待触发处理完成, 使测试结果确定
+type HandledTicker interface {
+	Ticker
+	// Handled 上一次触发已经处理完成
+	Handled()
+}
+
+var _ Clock = realClock{}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// now 当前时间的纳秒时间戳
+func (c *bCache[K, V]) now() int64 {
+	return c.config.clock.Now().UnixNano()
+}
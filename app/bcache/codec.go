@@ -0,0 +1,85 @@
+package bcache
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+var _ Codec[int] = GobCodec[int]{}
+
+// Codec 编解码器, 用于 SaveTo LoadFrom 序列化key以及value
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// GobCodec 使用 encoding/gob 编解码, 接口类型的具体类型需要提前 gob.Register
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// sessionCodec 可以在一个快照内复用状态的编解码器, 同一个快照内的记录按照写入顺序编码以及解码
+type sessionCodec[T any] interface {
+	session() Codec[T]
+}
+
+// withSession 返回一个快照使用的编解码器, 不支持复用状态时原样返回
+func withSession[T any](c Codec[T]) Codec[T] {
+	if s, ok := c.(sessionCodec[T]); ok {
+		return s.session()
+	}
+	return c
+}
+
+func (GobCodec[T]) session() Codec[T] {
+	return &gobSession[T]{}
+}
+
+// gobSession 在一个快照内共享 gob 的编码器以及解码器, 类型描述只在第一次出现时写入
+// Encode 返回的切片在下一次 Encode 之前有效
+type gobSession[T any] struct {
+	encBuf bytes.Buffer
+	enc    *gob.Encoder
+	decBuf bytes.Buffer
+	dec    *gob.Decoder
+}
+
+func (s *gobSession[T]) Encode(v T) ([]byte, error) {
+	if s.enc == nil {
+		s.enc = gob.NewEncoder(&s.encBuf)
+	}
+	s.encBuf.Reset()
+	if err := s.enc.Encode(&v); err != nil {
+		return nil, err
+	}
+	return s.encBuf.Bytes(), nil
+}
+
+func (s *gobSession[T]) Decode(data []byte) (T, error) {
+	if s.dec == nil {
+		s.dec = gob.NewDecoder(&s.decBuf)
+	}
+	var v T
+	s.decBuf.Write(data)
+	if err := s.dec.Decode(&v); err != nil {
+		return v, err
+	}
+	if s.decBuf.Len() != 0 {
+		// 每条记录恰好包含完整的 gob 消息
+		s.decBuf.Reset()
+		return v, ErrSnapshotCorrupt
+	}
+	return v, nil
+}
@@ -0,0 +1,38 @@
+package bcache
+
+// EvictReason kv被移除的原因
+type EvictReason int
+
+const (
+	// EvictExpired 过期
+	EvictExpired EvictReason = iota + 1
+	// EvictDeleted 主动删除
+	EvictDeleted
+	// EvictReplaced 被新值覆盖
+	EvictReplaced
+	// EvictCapacity 超出容量被淘汰
+	EvictCapacity
+	// EvictCleared 清空
+	EvictCleared
+	// EvictInvalidated 收到其他缓存发布的失效消息
+	EvictInvalidated
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "Expired"
+	case EvictDeleted:
+		return "Deleted"
+	case EvictReplaced:
+		return "Replaced"
+	case EvictCapacity:
+		return "Capacity"
+	case EvictCleared:
+		return "Cleared"
+	case EvictInvalidated:
+		return "Invalidated"
+	default:
+		return "Unknown"
+	}
+}
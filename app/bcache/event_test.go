@@ -0,0 +1,74 @@
+package bcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+type evictEvent struct {
+	k      string
+	v      int
+	reason EvictReason
+}
+
+func TestOnEvict(t *testing.T) {
+	var (
+		tc     *BCache[string, int]
+		events []evictEvent
+	)
+	tc = New[string, int](bcomparator.StringComparator(),
+		SetMaxEntries[string, int](3),
+		SetCapture[string, int](nil),
+		SetOnEvict[string, int](func(k string, v int, reason EvictReason) {
+			// 回调在锁外执行, 可以重入缓存
+			tc.Count()
+			events = append(events, evictEvent{k, v, reason})
+		}),
+	)
+
+	tc.Set("a", 1, DefaultExpire)
+	tc.Set("a", 2, DefaultExpire)
+	assert.Equal(t, []evictEvent{{"a", 1, EvictReplaced}}, events)
+
+	events = nil
+	tc.Replace("a", 3, DefaultExpire)
+	assert.Equal(t, []evictEvent{{"a", 2, EvictReplaced}}, events)
+
+	events = nil
+	tc.Delete("a")
+	tc.Delete("a")
+	assert.Equal(t, []evictEvent{{"a", 3, EvictDeleted}}, events)
+
+	events = nil
+	tc.Set("d", 1, DefaultExpire)
+	tc.Set("e", 2, DefaultExpire)
+	tc.Set("f", 3, DefaultExpire)
+	tc.Set("g", 4, DefaultExpire)
+	assert.Equal(t, []evictEvent{{"d", 1, EvictCapacity}}, events)
+
+	events = nil
+	tc.Clear()
+	assert.ElementsMatch(t, []evictEvent{{"e", 2, EvictCleared}, {"f", 3, EvictCleared}, {"g", 4, EvictCleared}}, events)
+}
+
+func TestCaptureReason(t *testing.T) {
+	var captured []string
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetMaxEntries[string, int](1),
+		SetCapture[string, int](func(k string, v int) {
+			captured = append(captured, k)
+		}),
+	)
+	tc.Set("a", 1, DefaultExpire)
+	tc.Set("a", 2, DefaultExpire)
+	tc.Set("b", 1, DefaultExpire)
+	tc.Delete("b")
+	tc.Set("c", 1, DefaultExpire)
+	tc.Clear()
+	assert.Equal(t, []string{"a", "b"}, captured)
+	assert.Equal(t, "Capacity", EvictCapacity.String())
+	assert.Equal(t, "Invalidated", EvictInvalidated.String())
+}
@@ -0,0 +1,70 @@
+package bcache
+
+import (
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+	"github.com/songzhibin97/go-baseutils/structure/sets/zset"
+)
+
+var (
+	_ expiryIndex[int] = (*zsetIndex[int])(nil)
+	_ expiryIndex[int] = (*timingWheel[int])(nil)
+)
+
+// expiryIndex 维护具有超时的key, 方法都在 bCache 的锁内调用
+type expiryIndex[K comparable] interface {
+	// add 添加k或更新k的过期时间
+	add(k K, expire int64)
+	// remove 移除k, k不存在时忽略
+	remove(k K)
+	// expire 移除并返回过期时间不晚于now的key, limit 大于0时最多返回limit个
+	expire(now int64, limit int) []K
+	// clear 清空
+	clear()
+}
+
+// newExpiryIndex 根据配置初始化过期索引, 默认使用 zset
+func (c *Config[K, V]) newExpiryIndex() expiryIndex[K] {
+	if c.wheelTick != 0 {
+		return newTimingWheel[K](c.wheelTick, c.clock.Now().UnixNano())
+	}
+	return newZsetIndex[K](c.comparator)
+}
+
+// zsetIndex 基于 zset 的过期索引, 以 float64(expire) 作为分数, 写入的复杂度为 O(log n)
+type zsetIndex[K comparable] struct {
+	comparator bcomparator.Comparator[K]
+	set        *zset.Set[K]
+}
+
+func newZsetIndex[K comparable](comparator bcomparator.Comparator[K]) *zsetIndex[K] {
+	return &zsetIndex[K]{
+		comparator: comparator,
+		set:        zset.New[K](comparator),
+	}
+}
+
+func (z *zsetIndex[K]) add(k K, expire int64) {
+	z.set.AddB(float64(expire), k)
+}
+
+func (z *zsetIndex[K]) remove(k K) {
+	z.set.Remove(k)
+}
+
+func (z *zsetIndex[K]) expire(now int64, limit int) []K {
+	var nodes []zset.Node[K]
+	if limit > 0 && z.set.Count(0, float64(now)) > limit {
+		nodes = z.set.RemoveRangeByRank(0, limit-1)
+	} else {
+		nodes = z.set.RemoveRangeByScore(0, float64(now))
+	}
+	ret := make([]K, len(nodes))
+	for i, n := range nodes {
+		ret[i] = n.Value
+	}
+	return ret
+}
+
+func (z *zsetIndex[K]) clear() {
+	z.set = zset.New[K](z.comparator)
+}
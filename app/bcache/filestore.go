@@ -0,0 +1,135 @@
+package bcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ Store[int, any] = (*FileStore[int, any])(nil)
+
+var ErrFileStoreCorrupt = errors.New("bcache: corrupt file store entry")
+
+// FileStore 基于本地文件的 Store, 每个key对应目录下的一个文件, 文件名为key编码后的sha256
+// 文件格式: varint(expire) uvarint(len(key)) key value
+// 写入时先写临时文件再重命名, 保证读取到的文件是完整的
+// 过期的文件在读取时视为不存在但不会删除, 避免误删并发 Set 重命名后的新文件, 由之后的 Set 覆盖或 Delete 删除
+type FileStore[K comparable, V any] struct {
+	dir   string
+	key   Codec[K]
+	value Codec[V]
+}
+
+// NewFileStore 初始化文件存储, dir 不存在时会创建, key value 为nil时使用 GobCodec
+func NewFileStore[K comparable, V any](dir string, key Codec[K], value Codec[V]) (*FileStore[K, V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if key == nil {
+		key = GobCodec[K]{}
+	}
+	if value == nil {
+		value = GobCodec[V]{}
+	}
+	return &FileStore[K, V]{dir: dir, key: key, value: value}, nil
+}
+
+// path 返回k对应的文件路径以及k编码后的字节
+func (s *FileStore[K, V]) path(k K) (string, []byte, error) {
+	kb, err := s.key.Encode(k)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(kb)
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])), kb, nil
+}
+
+func (s *FileStore[K, V]) Get(_ context.Context, k K) (V, time.Duration, bool, error) {
+	var zero V
+	name, kb, err := s.path(k)
+	if err != nil {
+		return zero, 0, false, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return zero, 0, false, nil
+	}
+	if err != nil {
+		return zero, 0, false, err
+	}
+	expire, n := binary.Varint(data)
+	if n <= 0 {
+		return zero, 0, false, ErrFileStoreCorrupt
+	}
+	data = data[n:]
+	size, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < size {
+		return zero, 0, false, ErrFileStoreCorrupt
+	}
+	data = data[n:]
+	if !bytes.Equal(data[:size], kb) {
+		// sha256 冲突, 视为不存在
+		return zero, 0, false, nil
+	}
+	ttl, ok := storeTTL(time.Now(), expire)
+	if !ok {
+		return zero, 0, false, nil
+	}
+	v, err := s.value.Decode(data[size:])
+	if err != nil {
+		return zero, 0, false, err
+	}
+	return v, ttl, true, nil
+}
+
+func (s *FileStore[K, V]) Set(_ context.Context, k K, v V, d time.Duration) error {
+	name, kb, err := s.path(k)
+	if err != nil {
+		return err
+	}
+	vb, err := s.value.Encode(v)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 2*binary.MaxVarintLen64, 2*binary.MaxVarintLen64+len(kb)+len(vb))
+	n := binary.PutVarint(buf, storeExpire(time.Now(), d))
+	n += binary.PutUvarint(buf[n:], uint64(len(kb)))
+	buf = append(buf[:n], kb...)
+	buf = append(buf, vb...)
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+func (s *FileStore[K, V]) Delete(_ context.Context, k K) error {
+	name, _, err := s.path(k)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -10,17 +10,28 @@ type Iterator[E any] struct {
 	// Expire 过期时间
 	// 0 不设置过期时间
 	Expire int64
+
+	// cost 成本, 由 Config.costFn 计算
+	cost int64
+	// ttl 写入时设置的存活时间, 用于提前刷新以及滑动过期
+	ttl int64
+	// sliding 是否滑动过期
+	sliding bool
+	// tags 写入时设置的tag, 用于 InvalidateTag
+	tags []string
+}
+
+// expired 判断在now时是否过期,过期返回 true, now 需要从缓存的 Clock 获取
+func (i Iterator[E]) expired(now int64) bool {
+	return i.isVisit() && now > i.Expire
 }
 
-// expired 判断是否过期,过期返回 true
-func (i Iterator[E]) expired(v ...int64) bool {
+// expireTime 返回过期时间, 不过期返回零值
+func (i Iterator[E]) expireTime() time.Time {
 	if !i.isVisit() {
-		return false
-	}
-	if len(v) != 0 {
-		return v[0] > i.Expire
+		return time.Time{}
 	}
-	return time.Now().UnixNano() > i.Expire
+	return time.Unix(0, i.Expire)
 }
 
 // IsVisit 根据expire判断是否需要监控
@@ -0,0 +1,120 @@
+package bcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/base/options"
+)
+
+// Janitor 共享的清理协程, 多个缓存共用一个哨兵周期清理过期数据, 避免每个缓存各自持有一个协程以及ticker
+type Janitor struct {
+	sync.Mutex
+	regs map[uint64]*registration
+	next uint64
+
+	lc *lifecycle
+}
+
+// NewJanitor 初始化共享清理协程, interval 为清理间隔
+func NewJanitor(interval time.Duration, opts ...options.Option[*JanitorConfig]) *Janitor {
+	c := &JanitorConfig{
+		clock: realClock{},
+	}
+	for _, option := range opts {
+		option(c)
+	}
+	j := &Janitor{
+		regs: make(map[uint64]*registration),
+		lc:   &lifecycle{},
+	}
+	j.lc.start(interval, j.run, nil, c.clock)
+	return j
+}
+
+// Close 停止清理协程并等待退出, 可以重复调用
+func (j *Janitor) Close() {
+	j.lc.close()
+}
+
+// registration 注册到 Janitor 的函数
+type registration struct {
+	fn func()
+	// running 正在执行的fn
+	running sync.WaitGroup
+}
+
+// register 注册周期执行的函数, 返回注销函数, 注销函数会等待正在执行的fn返回
+func (j *Janitor) register(fn func()) func() {
+	j.Lock()
+	defer j.Unlock()
+	id := j.next
+	j.next++
+	reg := &registration{fn: fn}
+	j.regs[id] = reg
+	return func() {
+		j.Lock()
+		delete(j.regs, id)
+		j.Unlock()
+		reg.running.Wait()
+	}
+}
+
+func (j *Janitor) run() {
+	j.Lock()
+	regs := make([]*registration, 0, len(j.regs))
+	for _, reg := range j.regs {
+		reg.running.Add(1)
+		regs = append(regs, reg)
+	}
+	j.Unlock()
+
+	for _, reg := range regs {
+		reg.fn()
+		reg.running.Done()
+	}
+}
+
+// lifecycle 管理哨兵协程的生命周期
+type lifecycle struct {
+	once sync.Once
+	// cancel 停止独占的哨兵
+	cancel context.CancelFunc
+	// done 独占的哨兵退出后关闭
+	done <-chan struct{}
+	// unregister 从共享的 Janitor 注销
+	unregister func()
+}
+
+// start 启动哨兵, 设置了 janitor 时注册到共享的清理协程
+func (l *lifecycle) start(interval time.Duration, fn func(), janitor *Janitor, clock Clock) {
+	if janitor != nil {
+		l.unregister = janitor.register(fn)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSentinel(ctx, interval, fn)
+	s.clock = clock
+	l.cancel = cancel
+	l.done = s.Done()
+	if interval <= 0 {
+		// 不开启哨兵
+		s.Start()
+		return
+	}
+	// 同步创建ticker, 保证哨兵的周期从缓存创建时开始计算
+	go s.run(clock.NewTicker(interval))
+}
+
+// close 停止哨兵并等待退出, 可以重复调用
+func (l *lifecycle) close() {
+	l.once.Do(func() {
+		if l.unregister != nil {
+			l.unregister()
+			return
+		}
+		l.cancel()
+		<-l.done
+	})
+}
@@ -0,0 +1,58 @@
+package bcache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestClose(t *testing.T) {
+	caches := make([]*BCache[string, int], 10)
+	for i := range caches {
+		caches[i] = New[string, int](bcomparator.StringComparator(), SetInternal[string, int](time.Millisecond))
+	}
+	for _, tc := range caches {
+		select {
+		case <-tc.lc.done:
+			t.Fatal("sentinel exited before Close")
+		default:
+		}
+		tc.Close()
+		tc.Close()
+		select {
+		case <-tc.lc.done:
+		default:
+			t.Fatal("Close returned before sentinel exited")
+		}
+	}
+
+	tc := New[string, int](bcomparator.StringComparator())
+	tc.Close()
+	tc.Set("a", 1, DefaultExpire)
+	v, ok := tc.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestJanitorCloseWaitsRunning(t *testing.T) {
+	j := NewJanitor(time.Millisecond)
+	defer j.Close()
+
+	started := make(chan struct{})
+	var once sync.Once
+	var done int32
+	unregister := j.register(func() {
+		once.Do(func() { close(started) })
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&done, 1)
+	})
+	<-started
+	// 注销后不会再有正在执行的fn
+	unregister()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&done))
+}
@@ -0,0 +1,23 @@
+package bcache
+
+import (
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/sys/fastrand"
+)
+
+// jitter 返回 [0, bound) 内的随机时长, bound 由 jitterPercent 与 jitterMax 计算
+// 同时设置时取两者中较小的值, 都未设置时返回0
+func (c *bCache[K, V]) jitter(ttl time.Duration) time.Duration {
+	var bound time.Duration
+	if c.config.jitterPercent > 0 {
+		bound = time.Duration(float64(ttl) * c.config.jitterPercent)
+	}
+	if c.config.jitterMax > 0 && (bound <= 0 || c.config.jitterMax < bound) {
+		bound = c.config.jitterMax
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(fastrand.Int63n(int64(bound)))
+}
@@ -0,0 +1,39 @@
+package bcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestJitter(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator(), SetJitterPercent[string, int](0.5))
+	expires := make(map[int64]struct{})
+	for i := 0; i < 100; i++ {
+		tc.Set("a", i, time.Minute)
+		ttl, ok := tc.TTL("a")
+		assert.True(t, ok)
+		assert.True(t, ttl > time.Minute-time.Second && ttl < time.Minute*3/2, ttl)
+		iter, _ := tc.member.Get("a")
+		expires[iter.Expire] = struct{}{}
+	}
+	assert.Greater(t, len(expires), 1)
+
+	// 同时设置时取较小的上限
+	tc = New[string, int](bcomparator.StringComparator(),
+		SetJitterPercent[string, int](0.5),
+		SetJitter[string, int](time.Second),
+		SetDefaultExpire[string, int](time.Minute),
+	)
+	for i := 0; i < 100; i++ {
+		tc.SetDefault("a", i)
+		ttl, _ := tc.TTL("a")
+		assert.True(t, ttl <= time.Minute+time.Second, ttl)
+	}
+	tc.SetNoExpire("b", 1)
+	ttl, _ := tc.TTL("b")
+	assert.Equal(t, NoExpire, ttl)
+}
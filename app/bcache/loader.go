@@ -0,0 +1,191 @@
+package bcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Loader 缓存未命中时加载数据, 返回值以及过期时间(与 Set 的 d 参数语义相同)
+type Loader[K comparable, V any] func(ctx context.Context, k K) (V, time.Duration, error)
+
+// call 进行中的加载
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// group 对相同key的并发加载去重, 同一时刻每个key只有一个加载在执行
+type group[K comparable, V any] struct {
+	sync.Mutex
+	calls map[K]*call[V]
+}
+
+// do 返回k对应的加载, 不存在时异步执行fn
+func (g *group[K, V]) do(k K, fn func() (V, error)) *call[V] {
+	g.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if cl, ok := g.calls[k]; ok {
+		g.Unlock()
+		return cl
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	g.calls[k] = cl
+	g.Unlock()
+
+	go func() {
+		defer func() {
+			g.Lock()
+			delete(g.calls, k)
+			g.Unlock()
+			close(cl.done)
+		}()
+		cl.val, cl.err = fn()
+	}()
+	return cl
+}
+
+// negative 加载失败的缓存
+type negative struct {
+	err    error
+	expire int64
+}
+
+func (c *bCache[K, V]) getOrLoad(ctx context.Context, k K, loader Loader[K, V]) (V, error) {
+	if v, _, ok := c.get(k); ok {
+		return v, nil
+	}
+	if err, ok := c.getNegative(k); ok {
+		return c.zero, err
+	}
+	cl := c.loads.do(k, func() (V, error) {
+		gen := c.beginLoad(k)
+		// 共享的加载不使用任何一个调用方的ctx, 调用方只在自己的ctx结束时放弃等待, Close 会取消进行中的加载
+		v, d, err := c.callLoader(c.ctx, k, loader)
+		if err != nil {
+			c.abortLoad(k, gen, err)
+			return c.zero, err
+		}
+		c.commitLoad(k, gen, c.newIterator(k, v, d), false)
+		return v, nil
+	})
+	select {
+	case <-ctx.Done():
+		return c.zero, ctx.Err()
+	case <-cl.done:
+		return cl.val, cl.err
+	}
+}
+
+// beginLoad 记录k开始加载时的代数
+func (c *bCache[K, V]) beginLoad(k K) uint64 {
+	c.Lock()
+	defer c.Unlock()
+	if c.loading == nil {
+		c.loading = make(map[K]uint64)
+	}
+	c.gen++
+	c.loading[k] = c.gen
+	return c.gen
+}
+
+// bumpLoad k被写入或删除时增加进行中的加载的代数, 使加载结果不再写回, 调用方需要持有锁
+func (c *bCache[K, V]) bumpLoad(k K) {
+	if _, ok := c.loading[k]; ok {
+		c.gen++
+		c.loading[k] = c.gen
+	}
+}
+
+// endLoad 结束k的加载, 加载期间k没有被写入 删除或清空时返回true, 调用方需要持有锁
+// 相同key同一时刻只有一个加载, 因此可以直接删除
+func (c *bCache[K, V]) endLoad(k K, gen uint64) bool {
+	cur, ok := c.loading[k]
+	delete(c.loading, k)
+	return ok && cur == gen
+}
+
+// commitLoad 加载成功后写回, 加载期间k被修改时放弃写回, keep 为true时保留原有的tag以及滑动过期
+func (c *bCache[K, V]) commitLoad(k K, gen uint64, iter Iterator[V], keep bool) {
+	c.Lock()
+	if !c.endLoad(k, gen) {
+		c.Unlock()
+		return
+	}
+	if ov, ok := c.member.Get(k); ok && keep {
+		iter.tags = ov.tags
+		iter.sliding = ov.sliding
+	}
+	evicted, _ := c.store(k, iter)
+	c.Unlock()
+
+	c.notify(evicted)
+}
+
+// abortLoad 加载失败后结束加载, 加载期间k没有被修改时缓存加载错误
+func (c *bCache[K, V]) abortLoad(k K, gen uint64, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if !c.endLoad(k, gen) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	c.setNegative(k, err)
+}
+
+// callLoader 调用loader并记录加载耗时以及是否失败, loader 的panic会转换为错误
+func (c *bCache[K, V]) callLoader(ctx context.Context, k K, loader Loader[K, V]) (v V, d time.Duration, err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			v, d, err = c.zero, 0, fmt.Errorf("bcache: loader panic: %v", r)
+		}
+		c.stats.load(time.Since(start), err)
+	}()
+	return loader(ctx, k)
+}
+
+// getNegative 获取k未过期的加载错误
+func (c *bCache[K, V]) getNegative(k K) (error, bool) {
+	if c.config.negativeExpire <= 0 {
+		return nil, false
+	}
+	c.Lock()
+	defer c.Unlock()
+	n, ok := c.negatives[k]
+	if !ok {
+		return nil, false
+	}
+	if c.now() > n.expire {
+		delete(c.negatives, k)
+		return nil, false
+	}
+	return n.err, true
+}
+
+// setNegative 缓存k的加载错误, 调用方需要持有锁
+func (c *bCache[K, V]) setNegative(k K, err error) {
+	if c.config.negativeExpire <= 0 {
+		return
+	}
+	if c.negatives == nil {
+		c.negatives = make(map[K]negative)
+	}
+	c.negatives[k] = negative{
+		err:    err,
+		expire: c.now() + int64(c.config.negativeExpire),
+	}
+}
+
+// deleteNegativeExpire 清除过期的加载错误, 调用方需要持有锁
+func (c *bCache[K, V]) deleteNegativeExpire(now int64) {
+	for k, n := range c.negatives {
+		if now > n.expire {
+			delete(c.negatives, k)
+		}
+	}
+}
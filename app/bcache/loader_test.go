@@ -0,0 +1,208 @@
+package bcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return len(k), NoExpire, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := tc.GetOrLoad(context.Background(), "foo", loader)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, v)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	v, ok := tc.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	v, err := tc.GetOrLoad(context.Background(), "foo", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetOrLoadNegative(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator(), SetNegativeExpire[string, int](20*time.Millisecond))
+
+	var calls int32
+	errLoad := errors.New("load failed")
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, 0, errLoad
+	}
+
+	_, err := tc.GetOrLoad(context.Background(), "foo", loader)
+	assert.ErrorIs(t, err, errLoad)
+	_, err = tc.GetOrLoad(context.Background(), "foo", loader)
+	assert.ErrorIs(t, err, errLoad)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	<-time.After(30 * time.Millisecond)
+	_, err = tc.GetOrLoad(context.Background(), "foo", loader)
+	assert.ErrorIs(t, err, errLoad)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	tc.Set("foo", 1, DefaultExpire)
+	v, err := tc.GetOrLoad(context.Background(), "foo", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestGetOrLoadContext(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+
+	release := make(chan struct{})
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		<-release
+		return 1, NoExpire, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := tc.GetOrLoad(ctx, "foo", loader)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	v, err := tc.GetOrLoad(context.Background(), "foo", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	_, err = tc.GetOrLoad(context.Background(), "bar", func(ctx context.Context, k string) (int, time.Duration, error) {
+		panic("boom")
+	})
+	assert.Error(t, err)
+	// panic 同样结束加载并记录为失败
+	tc.Lock()
+	assert.Empty(t, tc.loading)
+	tc.Unlock()
+	assert.Equal(t, uint64(1), tc.Stats().LoadFailures)
+}
+
+func TestGetOrLoadClose(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := tc.GetOrLoad(context.Background(), "foo", func(ctx context.Context, k string) (int, time.Duration, error) {
+			close(started)
+			<-ctx.Done()
+			return 0, 0, ctx.Err()
+		})
+		done <- err
+	}()
+	<-started
+	// Close 取消进行中的加载
+	tc.Close()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("load not canceled by Close")
+	}
+}
+
+func TestGetOrLoadSharedContext(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			return 0, 0, ctx.Err()
+		case <-release:
+			return 1, NoExpire, nil
+		}
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	errA := make(chan error, 1)
+	go func() {
+		_, err := tc.GetOrLoad(ctxA, "foo", loader)
+		errA <- err
+	}()
+	<-started
+
+	type result struct {
+		v   int
+		err error
+	}
+	resB := make(chan result, 1)
+	go func() {
+		v, err := tc.GetOrLoad(context.Background(), "foo", loader)
+		resB <- result{v, err}
+	}()
+
+	// A 放弃等待不影响B
+	cancelA()
+	assert.ErrorIs(t, <-errA, context.Canceled)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	r := <-resB
+	assert.NoError(t, r.err)
+	assert.Equal(t, 1, r.v)
+}
+
+func TestGetOrLoadStale(t *testing.T) {
+	for name, modify := range map[string]func(tc *BCache[string, int]){
+		"set":    func(tc *BCache[string, int]) { tc.Set("foo", 2, NoExpire) },
+		"delete": func(tc *BCache[string, int]) { tc.Delete("foo") },
+		"clear":  func(tc *BCache[string, int]) { tc.Clear() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+			started := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				v, err := tc.GetOrLoad(context.Background(), "foo", func(ctx context.Context, k string) (int, time.Duration, error) {
+					close(started)
+					<-release
+					return 1, NoExpire, nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, 1, v)
+			}()
+			<-started
+			modify(tc)
+			want, wantOK := tc.Get("foo")
+			close(release)
+			<-done
+
+			// 加载期间k被修改, 加载结果不会覆盖
+			v, ok := tc.Get("foo")
+			assert.Equal(t, wantOK, ok)
+			assert.Equal(t, want, v)
+		})
+	}
+}
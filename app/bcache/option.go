@@ -16,13 +16,66 @@ type Config[K comparable, V any] struct {
 	interval time.Duration
 	// fn 哨兵周期执行的函数
 	setSentinelFn func()
+	// janitor 共享的清理协程 设置后不再单独启动哨兵
+	janitor *Janitor
 
 	// capture 捕获删除对象时间 会返回kv值用于用户自定义处理
 	capture func(k K, v V)
 
+	// onEvict 捕获所有原因的移除 会返回kv值以及移除原因
+	onEvict func(k K, v V, reason EvictReason)
+
+	// maxEntries 最大缓存条目数 0 不限制
+	maxEntries int
+	// maxCost 最大总成本 0 不限制
+	maxCost int64
+	// costFn 计算kv的成本
+	costFn func(k K, v V) int64
+	// newPolicy 淘汰策略构造函数
+	newPolicy func() Policy[K]
+	// tinyLFU 使用 W-TinyLFU 淘汰策略
+	tinyLFU bool
+	// tinyLFUEntries W-TinyLFU 预计的最大条目数 0 使用 maxEntries
+	tinyLFUEntries int
+
+	// negativeExpire 加载失败的错误缓存时间 0 不缓存
+	negativeExpire time.Duration
+
+	// loader 后台刷新使用的加载函数
+	loader Loader[K, V]
+	// staleExpire 过期后仍可返回旧值的时间 0 不开启
+	staleExpire time.Duration
+	// refreshAhead 剩余存活时间小于该比例时提前刷新 0 不开启
+	refreshAhead float64
+
+	// jitterPercent 过期时间的随机抖动占存活时间的比例 0 不开启
+	jitterPercent float64
+	// jitterMax 过期时间的随机抖动上限 0 不限制
+	jitterMax time.Duration
+	// maxExpirePerTick 每次哨兵执行最多清理的过期数据 0 不限制
+	maxExpirePerTick int
+
+	// wheelTick 时间轮的 tick 0 使用 zset 维护过期索引
+	wheelTick time.Duration
+
+	// hasher 分片缓存选择分片使用的hash函数
+	hasher func(k K) uint64
+
 	member bmap.AnyBMap[K, V]
 
 	comparator bcomparator.Comparator[K]
+
+	// clock 时钟
+	clock Clock
+
+	// keyCodec valueCodec 快照使用的编解码器, keyCodec 同时用于失效广播
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+
+	// bus 失效广播
+	bus Bus
+	// onBusError 订阅或发布失效消息失败的回调
+	onBusError func(err error)
 }
 
 // SetInternal 设置间隔时间
@@ -46,9 +99,221 @@ func SetSentinelFn[K comparable, V any](fn func()) options.Option[*Config[K, V]]
 	}
 }
 
-// SetCapture 设置触发删除后的捕获函数, 数据删除后回调用设置的捕获函数
+// SetCapture 设置触发删除后的捕获函数, 数据主动删除或超出容量淘汰后回调用设置的捕获函数
 func SetCapture[K comparable, V any](capture func(k K, v V)) options.Option[*Config[K, V]] {
 	return func(c *Config[K, V]) {
 		c.capture = capture
 	}
 }
+
+// SetMaxEntries 设置最大缓存条目数, 超出后按淘汰策略淘汰, 默认(不设置)不限制
+func SetMaxEntries[K comparable, V any](n int) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.maxEntries = n
+	}
+}
+
+// SetPolicy 设置淘汰策略, 例如 NewLRUPolicy[K] NewLFUPolicy[K] NewFIFOPolicy[K], 默认(不设置)是 LRU
+func SetPolicy[K comparable, V any](newPolicy func() Policy[K]) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.newPolicy = newPolicy
+	}
+}
+
+// SetTinyLFU 使用 W-TinyLFU 淘汰策略, entries 为预计的最大条目数, 用于确定窗口大小以及频次估计的精度
+// entries 小于等于0时使用 SetMaxEntries 设置的值, 都未设置时使用 DefaultTinyLFUEntries
+// 只设置 SetMaxCost 时需要按照预计的条目数设置 entries, 频次估计占用的内存约为 4*entries 字节, 并且有上限
+// 分片缓存的 entries 平均分配到每个分片, 设置后 SetPolicy 不再生效
+func SetTinyLFU[K comparable, V any](entries int) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.tinyLFU = true
+		c.tinyLFUEntries = entries
+	}
+}
+
+// SetMaxCost 设置最大总成本, 超出后按淘汰策略淘汰, 成本超过上限的单个kv不会被写入, 默认(不设置)不限制
+func SetMaxCost[K comparable, V any](cost int64) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.maxCost = cost
+	}
+}
+
+// SetCostFunc 设置kv的成本计算函数, 默认(不设置)每个kv的成本为1
+func SetCostFunc[K comparable, V any](fn func(k K, v V) int64) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.costFn = fn
+	}
+}
+
+// SetNegativeExpire 设置 GetOrLoad 加载失败时错误的缓存时间, 期间相同key直接返回该错误, 默认(不设置)不缓存
+func SetNegativeExpire[K comparable, V any](expire time.Duration) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.negativeExpire = expire
+	}
+}
+
+// SetLoader 设置后台刷新使用的加载函数, 配合 SetStaleExpire SetRefreshAhead 使用
+func SetLoader[K comparable, V any](loader Loader[K, V]) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.loader = loader
+	}
+}
+
+// SetStaleExpire 设置过期后仍可返回旧值的时间, 期间访问会返回旧值并在后台调用 loader 刷新, 默认(不设置)不开启
+func SetStaleExpire[K comparable, V any](expire time.Duration) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.staleExpire = expire
+	}
+}
+
+// SetRefreshAhead 设置提前刷新的比例, 剩余存活时间小于 存活时间*factor 时访问会在后台调用 loader 刷新, 默认(不设置)不开启
+func SetRefreshAhead[K comparable, V any](factor float64) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.refreshAhead = factor
+	}
+}
+
+// SetHasher 设置分片缓存选择分片使用的hash函数, 默认(不设置)字符串与数值类型使用 wyhash 直接计算
+// 其他类型的key默认按照 fmt 格式化后计算, 每次读写都会分配内存, 建议设置专用的hash函数
+func SetHasher[K comparable, V any](hasher func(k K) uint64) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.hasher = hasher
+	}
+}
+
+// SetOnEvict 设置移除回调, 过期 删除 覆盖 淘汰 清空 都会在锁外回调并携带移除原因
+func SetOnEvict[K comparable, V any](fn func(k K, v V, reason EvictReason)) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.onEvict = fn
+	}
+}
+
+// SetJanitor 设置共享的清理协程, 多个缓存共用一个协程周期清理, 设置后 SetInternal 不再生效
+func SetJanitor[K comparable, V any](janitor *Janitor) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.janitor = janitor
+	}
+}
+
+// SetJitterPercent 设置过期时间的随机抖动, 过期时间会在存活时间的基础上随机顺延 [0, ttl*percent)
+// 避免同时写入的大量kv在同一时刻过期, 默认(不设置)不开启
+func SetJitterPercent[K comparable, V any](percent float64) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.jitterPercent = percent
+	}
+}
+
+// SetJitter 设置过期时间的随机抖动上限, 过期时间会随机顺延 [0, d)
+// 与 SetJitterPercent 同时设置时作为抖动的上限, 默认(不设置)不开启
+func SetJitter[K comparable, V any](d time.Duration) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.jitterMax = d
+	}
+}
+
+// SetMaxExpirePerTick 设置每次哨兵执行最多清理的过期数据, 剩余的过期数据留到后续执行清理
+// 过期但尚未清理的数据不会被 Get 返回, 默认(不设置)不限制
+func SetMaxExpirePerTick[K comparable, V any](n int) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.maxExpirePerTick = n
+	}
+}
+
+// SetTimingWheel 使用分层时间轮维护过期索引, 写入的复杂度为 O(1), 过期的精度为 tick(向上取整为2的幂纳秒)
+// tick 小于等于0时约为1ms, 最大约为4s, 默认(不设置)使用 zset 维护过期索引
+func SetTimingWheel[K comparable, V any](tick time.Duration) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.wheelTick = tick
+		if tick <= 0 {
+			c.wheelTick = -1
+		}
+	}
+}
+
+// SetClock 设置时钟, 用于判断过期以及驱动哨兵, 默认(不设置)使用系统时钟
+func SetClock[K comparable, V any](clock Clock) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.clock = clock
+	}
+}
+
+// SetKeyCodec 设置 SaveTo LoadFrom 使用的key编解码器, 默认(不设置)使用 GobCodec
+func SetKeyCodec[K comparable, V any](codec Codec[K]) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.keyCodec = codec
+	}
+}
+
+// SetValueCodec 设置 SaveTo LoadFrom 使用的value编解码器, 默认(不设置)使用 GobCodec
+func SetValueCodec[K comparable, V any](codec Codec[V]) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.valueCodec = codec
+	}
+}
+
+// SetBus 设置失效广播, 写入或删除key时发布失效消息, 收到其他缓存的消息后删除本地的key
+// 消息中的key使用 SetKeyCodec 设置的编解码器编码, 订阅在 Close 时取消
+func SetBus[K comparable, V any](bus Bus) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.bus = bus
+	}
+}
+
+// SetBusErrorHandler 设置失效广播出错的回调, 初始化时订阅失败(例如bus已经关闭)以及发布失败时调用
+// 订阅失败时缓存不会收到其他缓存的失效消息, 默认(不设置)忽略错误
+func SetBusErrorHandler[K comparable, V any](fn func(err error)) options.Option[*Config[K, V]] {
+	return func(c *Config[K, V]) {
+		c.onBusError = fn
+	}
+}
+
+// TieredConfig 二级缓存的配置
+type TieredConfig[K comparable, V any] struct {
+	// writeBehind 是否延迟写回二级存储
+	writeBehind bool
+	// flushInterval 后台写回的间隔
+	flushInterval time.Duration
+	// flushBatch 等待写回的操作达到该数量时同步写回 0 不限制
+	flushBatch int
+	// onFlushError 后台写回失败的回调
+	onFlushError func(err error)
+
+	// fillExpire 一级缓存的最大过期时间 0 与二级存储一致
+	fillExpire time.Duration
+}
+
+// SetWriteBehind 开启延迟写回, 每隔interval或等待的操作达到batch时批量写回二级存储
+func SetWriteBehind[K comparable, V any](interval time.Duration, batch int) options.Option[*TieredConfig[K, V]] {
+	return func(c *TieredConfig[K, V]) {
+		c.writeBehind = true
+		c.flushInterval = interval
+		c.flushBatch = batch
+	}
+}
+
+// SetFlushErrorHandler 设置后台写回失败的回调
+func SetFlushErrorHandler[K comparable, V any](fn func(err error)) options.Option[*TieredConfig[K, V]] {
+	return func(c *TieredConfig[K, V]) {
+		c.onFlushError = fn
+	}
+}
+
+// SetFillExpire 设置一级缓存的最大过期时间, 避免一级缓存长期持有二级存储中已变更的数据
+func SetFillExpire[K comparable, V any](d time.Duration) options.Option[*TieredConfig[K, V]] {
+	return func(c *TieredConfig[K, V]) {
+		c.fillExpire = d
+	}
+}
+
+type JanitorConfig struct {
+	// clock 驱动清理协程的时钟
+	clock Clock
+}
+
+// SetJanitorClock 设置驱动共享清理协程的时钟, 默认(不设置)使用系统时钟
+// 使用共享清理协程的缓存需要通过 SetClock 设置同一个时钟
+func SetJanitorClock(clock Clock) options.Option[*JanitorConfig] {
+	return func(c *JanitorConfig) {
+		c.clock = clock
+	}
+}
@@ -0,0 +1,222 @@
+package bcache
+
+import "container/list"
+
+var (
+	_ Policy[int] = (*lruPolicy[int])(nil)
+	_ Policy[int] = (*lfuPolicy[int])(nil)
+	_ Policy[int] = (*fifoPolicy[int])(nil)
+)
+
+// Policy 淘汰策略, 维护key的先后顺序并在容量超限时选出需要淘汰的key
+// Policy 的方法都在 bCache 的锁内调用, 实现无需保证并发安全
+type Policy[K comparable] interface {
+	// Add 新增key, key已存在时视为一次更新
+	Add(k K)
+	// Access 访问key
+	Access(k K)
+	// Remove 移除key, key不存在时忽略
+	Remove(k K)
+	// Evict 选出并移除需要淘汰的key, 没有可淘汰的key返回false
+	Evict() (K, bool)
+	// Clear 清空
+	Clear()
+}
+
+// =====================================================================================================================
+// lru
+
+type lruPolicy[K comparable] struct {
+	// ll 最近访问的key在前
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+// NewLRUPolicy 最近最少使用淘汰策略
+func NewLRUPolicy[K comparable]() Policy[K] {
+	return &lruPolicy[K]{
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+func (p *lruPolicy[K]) Add(k K) {
+	if e, ok := p.items[k]; ok {
+		p.ll.MoveToFront(e)
+		return
+	}
+	p.items[k] = p.ll.PushFront(k)
+}
+
+func (p *lruPolicy[K]) Access(k K) {
+	if e, ok := p.items[k]; ok {
+		p.ll.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy[K]) Remove(k K) {
+	if e, ok := p.items[k]; ok {
+		p.ll.Remove(e)
+		delete(p.items, k)
+	}
+}
+
+func (p *lruPolicy[K]) Evict() (K, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		var zero K
+		return zero, false
+	}
+	k := p.ll.Remove(e).(K)
+	delete(p.items, k)
+	return k, true
+}
+
+func (p *lruPolicy[K]) Clear() {
+	p.ll.Init()
+	p.items = make(map[K]*list.Element)
+}
+
+// =====================================================================================================================
+// lfu
+
+// lfuBucket 访问频次相同的key, 最近访问的key在前
+type lfuBucket[K comparable] struct {
+	freq  int
+	items *list.List
+}
+
+// lfuItem 记录key所在的频次桶以及在桶内的位置
+type lfuItem[K comparable] struct {
+	bucket *list.Element
+	elem   *list.Element
+}
+
+type lfuPolicy[K comparable] struct {
+	// buckets 按频次从小到大排列的频次桶
+	buckets *list.List
+	items   map[K]*lfuItem[K]
+}
+
+// NewLFUPolicy 最不经常使用淘汰策略, 频次相同时淘汰最久未访问的key
+func NewLFUPolicy[K comparable]() Policy[K] {
+	return &lfuPolicy[K]{
+		buckets: list.New(),
+		items:   make(map[K]*lfuItem[K]),
+	}
+}
+
+func (p *lfuPolicy[K]) Add(k K) {
+	if _, ok := p.items[k]; ok {
+		p.Access(k)
+		return
+	}
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket[K]).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket[K]{freq: 1, items: list.New()})
+	}
+	p.items[k] = &lfuItem[K]{
+		bucket: front,
+		elem:   front.Value.(*lfuBucket[K]).items.PushFront(k),
+	}
+}
+
+func (p *lfuPolicy[K]) Access(k K) {
+	item, ok := p.items[k]
+	if !ok {
+		return
+	}
+	cur := item.bucket.Value.(*lfuBucket[K])
+	next := item.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket[K]).freq != cur.freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket[K]{freq: cur.freq + 1, items: list.New()}, item.bucket)
+	}
+	cur.items.Remove(item.elem)
+	if cur.items.Len() == 0 {
+		p.buckets.Remove(item.bucket)
+	}
+	item.bucket = next
+	item.elem = next.Value.(*lfuBucket[K]).items.PushFront(k)
+}
+
+func (p *lfuPolicy[K]) Remove(k K) {
+	item, ok := p.items[k]
+	if !ok {
+		return
+	}
+	p.unlink(item)
+	delete(p.items, k)
+}
+
+func (p *lfuPolicy[K]) Evict() (K, bool) {
+	front := p.buckets.Front()
+	if front == nil {
+		var zero K
+		return zero, false
+	}
+	k := front.Value.(*lfuBucket[K]).items.Back().Value.(K)
+	p.Remove(k)
+	return k, true
+}
+
+func (p *lfuPolicy[K]) Clear() {
+	p.buckets.Init()
+	p.items = make(map[K]*lfuItem[K])
+}
+
+func (p *lfuPolicy[K]) unlink(item *lfuItem[K]) {
+	b := item.bucket.Value.(*lfuBucket[K])
+	b.items.Remove(item.elem)
+	if b.items.Len() == 0 {
+		p.buckets.Remove(item.bucket)
+	}
+}
+
+// =====================================================================================================================
+// fifo
+
+type fifoPolicy[K comparable] struct {
+	// ll 最先写入的key在后
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+// NewFIFOPolicy 先进先出淘汰策略, 更新与访问不影响淘汰顺序
+func NewFIFOPolicy[K comparable]() Policy[K] {
+	return &fifoPolicy[K]{
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+func (p *fifoPolicy[K]) Add(k K) {
+	if _, ok := p.items[k]; ok {
+		return
+	}
+	p.items[k] = p.ll.PushFront(k)
+}
+
+func (p *fifoPolicy[K]) Access(K) {}
+
+func (p *fifoPolicy[K]) Remove(k K) {
+	if e, ok := p.items[k]; ok {
+		p.ll.Remove(e)
+		delete(p.items, k)
+	}
+}
+
+func (p *fifoPolicy[K]) Evict() (K, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		var zero K
+		return zero, false
+	}
+	k := p.ll.Remove(e).(K)
+	delete(p.items, k)
+	return k, true
+}
+
+func (p *fifoPolicy[K]) Clear() {
+	p.ll.Init()
+	p.items = make(map[K]*list.Element)
+}
@@ -0,0 +1,142 @@
+package bcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func evictAll[K comparable](p Policy[K]) (ret []K) {
+	for {
+		k, ok := p.Evict()
+		if !ok {
+			return ret
+		}
+		ret = append(ret, k)
+	}
+}
+
+func TestLRUPolicy(t *testing.T) {
+	p := NewLRUPolicy[int]()
+	p.Add(1)
+	p.Add(2)
+	p.Add(3)
+	p.Access(1)
+	p.Add(2)
+	p.Remove(4)
+	assert.Equal(t, []int{3, 1, 2}, evictAll(p))
+
+	p.Add(1)
+	p.Clear()
+	assert.Empty(t, evictAll(p))
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := NewLFUPolicy[int]()
+	p.Add(1)
+	p.Add(2)
+	p.Add(3)
+	p.Access(1)
+	p.Access(1)
+	p.Access(3)
+	p.Add(4)
+	p.Remove(2)
+	assert.Equal(t, []int{4, 3, 1}, evictAll(p))
+
+	p.Add(1)
+	p.Clear()
+	assert.Empty(t, evictAll(p))
+}
+
+func TestFIFOPolicy(t *testing.T) {
+	p := NewFIFOPolicy[int]()
+	p.Add(1)
+	p.Add(2)
+	p.Add(3)
+	p.Access(1)
+	p.Add(1)
+	p.Remove(2)
+	assert.Equal(t, []int{1, 3}, evictAll(p))
+
+	p.Add(1)
+	p.Clear()
+	assert.Empty(t, evictAll(p))
+}
+
+func TestMaxEntries(t *testing.T) {
+	var evicted []string
+	capture := SetCapture[string, int](func(k string, v int) {
+		evicted = append(evicted, k)
+	})
+
+	tc := New[string, int](bcomparator.StringComparator(), SetMaxEntries[string, int](2), capture)
+	tc.Set("a", 1, DefaultExpire)
+	tc.Set("b", 2, DefaultExpire)
+	tc.Get("a")
+	tc.Set("c", 3, DefaultExpire)
+	assert.Equal(t, 2, tc.Count())
+	assert.Equal(t, []string{"b"}, evicted)
+	_, ok := tc.Get("b")
+	assert.False(t, ok)
+
+	evicted = nil
+	tc = New[string, int](bcomparator.StringComparator(), SetMaxEntries[string, int](2), SetPolicy[string, int](NewFIFOPolicy[string]), capture)
+	tc.Set("a", 1, DefaultExpire)
+	tc.Set("b", 2, DefaultExpire)
+	tc.Get("a")
+	assert.True(t, tc.SetIfAbsent("c", 3, DefaultExpire))
+	assert.Equal(t, 2, tc.Count())
+	assert.Equal(t, []string{"a"}, evicted)
+
+	evicted = nil
+	tc = New[string, int](bcomparator.StringComparator(), SetMaxEntries[string, int](2), SetPolicy[string, int](NewLFUPolicy[string]), capture)
+	tc.Set("a", 1, DefaultExpire)
+	tc.Set("b", 2, DefaultExpire)
+	tc.Get("a")
+	tc.Get("b")
+	tc.Get("b")
+	tc.Set("c", 3, DefaultExpire)
+	tc.Set("d", 4, DefaultExpire)
+	assert.Equal(t, 2, tc.Count())
+	assert.Equal(t, []string{"a", "c"}, evicted)
+}
+
+func TestMaxCost(t *testing.T) {
+	var evicted []string
+	tc := New[string, string](bcomparator.StringComparator(),
+		SetMaxCost[string, string](10),
+		SetCostFunc[string, string](func(k string, v string) int64 {
+			return int64(len(v))
+		}),
+		SetCapture[string, string](func(k string, v string) {
+			evicted = append(evicted, k)
+		}),
+	)
+	tc.Set("a", "aaaa", DefaultExpire)
+	tc.Set("b", "bbbb", DefaultExpire)
+	assert.Equal(t, int64(8), tc.Cost())
+
+	tc.Set("c", "cccc", DefaultExpire)
+	assert.Equal(t, int64(8), tc.Cost())
+	assert.Equal(t, []string{"a"}, evicted)
+
+	tc.Set("b", "bb", DefaultExpire)
+	assert.Equal(t, int64(6), tc.Cost())
+
+	tc.Set("d", "ddddddddddd", DefaultExpire)
+	_, ok := tc.Get("d")
+	assert.False(t, ok)
+	assert.Equal(t, int64(6), tc.Cost())
+
+	tc.Set("b", "bbbbbbbbbbbb", DefaultExpire)
+	_, ok = tc.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, int64(4), tc.Cost())
+	assert.Equal(t, []string{"a", "b"}, evicted)
+
+	tc.Delete("c")
+	assert.Equal(t, int64(0), tc.Cost())
+	assert.Equal(t, 0, tc.Count())
+}
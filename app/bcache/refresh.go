@@ -0,0 +1,43 @@
+package bcache
+
+// deadline 返回k的最终过期时间, 开启 staleExpire 后会在 Expire 基础上顺延
+func (c *bCache[K, V]) deadline(iter Iterator[V]) int64 {
+	if !iter.isVisit() || c.config.staleExpire <= 0 {
+		return iter.Expire
+	}
+	return iter.Expire + int64(c.config.staleExpire)
+}
+
+// expired 判断是否超过最终过期时间, 超过返回 true
+func (c *bCache[K, V]) expired(iter Iterator[V], now int64) bool {
+	return iter.isVisit() && now > c.deadline(iter)
+}
+
+// needRefresh 判断是否需要在后台刷新
+func (c *bCache[K, V]) needRefresh(iter Iterator[V], now int64) bool {
+	if c.config.loader == nil || !iter.isVisit() {
+		return false
+	}
+	if c.config.staleExpire > 0 && iter.expired(now) {
+		return true
+	}
+	return c.config.refreshAhead > 0 && iter.ttl > 0 &&
+		float64(iter.Expire-now) < float64(iter.ttl)*c.config.refreshAhead
+}
+
+// refresh 在后台调用 loader 刷新k, 与 GetOrLoad 共享去重, 失败时保留旧值, 刷新后保留原有的tag以及滑动过期
+// 刷新期间k被写入 删除或清空时放弃写回, Close 会取消进行中的刷新
+func (c *bCache[K, V]) refresh(k K) {
+	c.loads.do(k, func() (V, error) {
+		gen := c.beginLoad(k)
+		v, d, err := c.callLoader(c.ctx, k, c.config.loader)
+		if err != nil {
+			c.Lock()
+			c.endLoad(k, gen)
+			c.Unlock()
+			return c.zero, err
+		}
+		c.commitLoad(k, gen, c.newIterator(k, v, d), true)
+		return v, nil
+	})
+}
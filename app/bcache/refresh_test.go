@@ -0,0 +1,137 @@
+package bcache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestStaleExpire(t *testing.T) {
+	var calls int32
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		return int(atomic.AddInt32(&calls, 1)) + 1, 20 * time.Millisecond, nil
+	}
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetLoader[string, int](loader),
+		SetStaleExpire[string, int](50*time.Millisecond),
+	)
+	tc.Set("foo", 1, 20*time.Millisecond)
+
+	<-time.After(30 * time.Millisecond)
+	v, ok := tc.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Eventually(t, func() bool {
+		v, ok := tc.Get("foo")
+		return ok && v == 2
+	}, time.Second, time.Millisecond)
+
+	tc.Set("bar", 1, 10*time.Millisecond)
+	<-time.After(70 * time.Millisecond)
+	_, ok = tc.Get("bar")
+	assert.False(t, ok)
+}
+
+func TestRefreshAhead(t *testing.T) {
+	var calls int32
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		return int(atomic.AddInt32(&calls, 1)) + 1, 100 * time.Millisecond, nil
+	}
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetLoader[string, int](loader),
+		SetRefreshAhead[string, int](0.5),
+	)
+	tc.Set("foo", 1, 100*time.Millisecond)
+
+	v, _ := tc.Get("foo")
+	assert.Equal(t, 1, v)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	<-time.After(60 * time.Millisecond)
+	v, ok := tc.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Eventually(t, func() bool {
+		v, ok := tc.Get("foo")
+		return ok && v == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRefreshStale(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		started <- struct{}{}
+		<-release
+		return 3, time.Minute, nil
+	}
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetLoader[string, int](loader),
+		SetRefreshAhead[string, int](1),
+	)
+	tc.Set("foo", 1, time.Minute)
+	v, _ := tc.Get("foo")
+	assert.Equal(t, 1, v)
+	<-started
+
+	// 刷新期间写入的值不会被刷新结果覆盖
+	tc.Set("foo", 2, NoExpire)
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+	v, ok := tc.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+}
+
+func TestRefreshKeepSliding(t *testing.T) {
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		return 2, time.Minute, nil
+	}
+	tc := New[string, int](bcomparator.StringComparator(), SetLoader[string, int](loader))
+	tc.SetSliding("bar", 1, time.Minute)
+
+	tc.refresh("bar")
+	assert.Eventually(t, func() bool {
+		v, _ := tc.Get("bar")
+		return v == 2
+	}, time.Second, time.Millisecond)
+	tc.RLock()
+	iter, _ := tc.member.Get("bar")
+	tc.RUnlock()
+	// 刷新后仍然是滑动过期
+	assert.True(t, iter.sliding)
+}
+
+func TestRefreshClose(t *testing.T) {
+	started := make(chan struct{}, 1)
+	canceled := make(chan struct{})
+	loader := func(ctx context.Context, k string) (int, time.Duration, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		close(canceled)
+		return 0, 0, ctx.Err()
+	}
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetLoader[string, int](loader),
+		SetRefreshAhead[string, int](1),
+	)
+	tc.Set("foo", 1, time.Minute)
+	tc.Get("foo")
+	<-started
+
+	tc.Close()
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("refresh not canceled")
+	}
+	v, ok := tc.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
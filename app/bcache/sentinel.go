@@ -13,28 +13,52 @@ type sentinel struct {
 	ctx context.Context
 	// fn 哨兵周期执行的函数
 	fn func()
+	// done 哨兵退出后关闭
+	done chan struct{}
+	// clock 驱动哨兵的时钟
+	clock Clock
 }
 
 func (s *sentinel) Start() {
 	if s.interval <= 0 {
+		close(s.done)
 		return
 	}
-	tick := time.NewTicker(s.interval)
+	s.run(s.clock.NewTicker(s.interval))
+}
+
+// run 按照tick周期执行fn, 直到ctx结束
+func (s *sentinel) run(tick Ticker) {
+	defer close(s.done)
 	defer tick.Stop()
+	handled, _ := tick.(HandledTicker)
+	if handled != nil {
+		handled.Handled()
+	}
 	for {
 		select {
-		case <-tick.C:
+		case <-tick.C():
 			s.fn()
+			if handled != nil {
+				handled.Handled()
+			}
 		case <-s.ctx.Done():
 			return
 		}
 	}
 }
 
+// Done 哨兵退出后关闭
+func (s *sentinel) Done() <-chan struct{} {
+	return s.done
+}
+
 func NewSentinel(ctx context.Context, interval time.Duration, fn func()) *sentinel {
 	return &sentinel{
 		interval: interval,
 		ctx:      ctx,
 		fn:       fn,
+		done:     make(chan struct{}),
+		clock:    realClock{},
 	}
 }
@@ -0,0 +1,383 @@
+package bcache
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+	"github.com/songzhibin97/go-baseutils/base/bmap"
+	"github.com/songzhibin97/go-baseutils/base/options"
+	"github.com/songzhibin97/go-baseutils/internal/keyhash"
+)
+
+var _ Cache[int, any] = (*ShardedBCache[int, any])(nil)
+
+// ShardedBCache 分片缓存, 按照key的hash将kv分散到多个分片, 每个分片独立加锁并维护各自的map以及过期索引
+type ShardedBCache[K comparable, V any] struct {
+	shards []*bCache[K, V]
+	hasher func(k K) uint64
+	mask   uint64
+	lc     *lifecycle
+	bus    *broadcaster[K]
+}
+
+// NewSharded 初始化分片缓存, shards 会向上取整为2的幂
+// SetMaxEntries SetMaxCost 设置的上限会拆分到每个分片, 各分片的上限之和等于设置的上限, 每个分片独立淘汰
+// 因此总量不会超过上限, 但单个分片满了以后即使其他分片还有空间也会淘汰, 成本超过分片上限的单个kv不会被写入
+// 分片数量超过 SetMaxEntries 或 SetMaxCost 时会减少到不超过上限的2的幂, 保证每个分片至少可以容纳1
+// SetMaxExpirePerTick 设置的上限会平均分配到每个分片
+func NewSharded[K comparable, V any](comparator bcomparator.Comparator[K], shards int, opts ...options.Option[*Config[K, V]]) *ShardedBCache[K, V] {
+	c := newConfig(comparator, opts...)
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	for n > 1 && ((c.maxEntries > 0 && n > c.maxEntries) || (c.maxCost > 0 && int64(n) > c.maxCost)) {
+		n >>= 1
+	}
+	s := &ShardedBCache[K, V]{
+		shards: make([]*bCache[K, V], n),
+		hasher: c.hasher,
+		mask:   uint64(n - 1),
+		lc:     &lifecycle{},
+	}
+	if s.hasher == nil {
+		s.hasher = keyhash.Default[K]()
+	}
+	for i := range s.shards {
+		sc := *c
+		sc.maxEntries = int(splitLimit(int64(c.maxEntries), n, i))
+		sc.maxCost = splitLimit(c.maxCost, n, i)
+		sc.maxExpirePerTick = int(perShard(int64(c.maxExpirePerTick), n))
+		sc.tinyLFUEntries = int(perShard(int64(c.tinyLFUEntries), n))
+		s.shards[i] = newBCache(&sc)
+	}
+	if c.setSentinelFn == nil {
+		c.setSentinelFn = s.deleteExpire
+	}
+	s.lc.start(c.interval, c.setSentinelFn, c.janitor, c.clock)
+	s.bus = newBroadcaster[K](c.bus, c.keyCodec, c.onBusError, func(k K) {
+		s.shard(k).invalidate(k)
+	}, s.clear)
+	return s
+}
+
+// splitLimit 将上限拆分到n个分片, 返回第i个分片的上限, 余数分配给前面的分片, 各分片之和等于limit
+func splitLimit(limit int64, n, i int) int64 {
+	if limit <= 0 {
+		return limit
+	}
+	per := limit / int64(n)
+	if int64(i) < limit%int64(n) {
+		per++
+	}
+	return per
+}
+
+// perShard 将上限平均分配到n个分片, 向上取整
+func perShard(limit int64, n int) int64 {
+	if limit <= 0 {
+		return limit
+	}
+	return (limit + int64(n) - 1) / int64(n)
+}
+
+func (s *ShardedBCache[K, V]) shard(k K) *bCache[K, V] {
+	return s.shards[s.hasher(k)&s.mask]
+}
+
+func (s *ShardedBCache[K, V]) Set(k K, v V, d time.Duration) {
+	s.shard(k).set(k, v, d)
+	s.bus.publish(k)
+}
+
+func (s *ShardedBCache[K, V]) SetDefault(k K, v V) {
+	s.shard(k).set(k, v, DefaultExpire)
+	s.bus.publish(k)
+}
+
+func (s *ShardedBCache[K, V]) SetNoExpire(k K, v V) {
+	s.shard(k).set(k, v, NoExpire)
+	s.bus.publish(k)
+}
+
+func (s *ShardedBCache[K, V]) SetSliding(k K, v V, d time.Duration) {
+	s.shard(k).setSliding(k, v, d)
+	s.bus.publish(k)
+}
+
+func (s *ShardedBCache[K, V]) SetWithTags(k K, v V, d time.Duration, tags ...string) {
+	s.shard(k).setWithTags(k, v, d, tags)
+	s.bus.publish(k)
+}
+
+func (s *ShardedBCache[K, V]) SetIfAbsent(k K, v V, d time.Duration) bool {
+	ok := s.shard(k).setIfAbsent(k, v, d)
+	if ok {
+		s.bus.publish(k)
+	}
+	return ok
+}
+
+func (s *ShardedBCache[K, V]) Replace(k K, v V, d time.Duration) bool {
+	ok := s.shard(k).replace(k, v, d)
+	if ok {
+		s.bus.publish(k)
+	}
+	return ok
+}
+
+func (s *ShardedBCache[K, V]) Delete(k K) {
+	s.shard(k).Delete(k)
+	s.bus.publish(k)
+}
+
+// GetMany 按分片分组后, 每个分片加锁一次获取
+func (s *ShardedBCache[K, V]) GetMany(ks []K) map[K]V {
+	ret := make(map[K]V, len(ks))
+	for i, part := range s.groupKeys(ks) {
+		if len(part) == 0 {
+			continue
+		}
+		for k, v := range s.shards[i].getMany(part) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// SetMany 按分片分组后, 每个分片加锁一次写入
+func (s *ShardedBCache[K, V]) SetMany(mp map[K]V, d time.Duration) {
+	parts := make([]map[K]V, len(s.shards))
+	for k, v := range mp {
+		i := s.hasher(k) & s.mask
+		if parts[i] == nil {
+			parts[i] = make(map[K]V)
+		}
+		parts[i][k] = v
+	}
+	for i, part := range parts {
+		if part != nil {
+			s.shards[i].setMany(part, d)
+		}
+	}
+	for k := range mp {
+		s.bus.publish(k)
+	}
+}
+
+// DeleteMany 按分片分组后, 每个分片加锁一次删除
+func (s *ShardedBCache[K, V]) DeleteMany(ks []K) {
+	for i, part := range s.groupKeys(ks) {
+		if len(part) != 0 {
+			s.shards[i].deleteMany(part)
+		}
+	}
+	s.bus.publish(ks...)
+}
+
+// groupKeys 按分片对key分组
+func (s *ShardedBCache[K, V]) groupKeys(ks []K) [][]K {
+	parts := make([][]K, len(s.shards))
+	for _, k := range ks {
+		i := s.hasher(k) & s.mask
+		parts[i] = append(parts[i], k)
+	}
+	return parts
+}
+
+func (s *ShardedBCache[K, V]) Keys() []K {
+	var ret []K
+	for _, sh := range s.shards {
+		ret = append(ret, sh.keys()...)
+	}
+	return ret
+}
+
+// Range 依次复制每个分片未过期的kv并在锁外回调, fn 返回false时停止
+func (s *ShardedBCache[K, V]) Range(fn func(k K, v V, expire time.Time) bool) {
+	for _, sh := range s.shards {
+		if !sh.rangeAlive(fn) {
+			return
+		}
+	}
+}
+
+// InvalidateTag 同时锁住所有分片后删除带有tag的所有kv, 解锁后回调, 返回删除的数量
+func (s *ShardedBCache[K, V]) InvalidateTag(tag string) int {
+	removed := make([][]entry[K, V], len(s.shards))
+	for _, sh := range s.shards {
+		sh.Lock()
+	}
+	for i, sh := range s.shards {
+		removed[i] = sh.invalidateTag(tag)
+	}
+	for _, sh := range s.shards {
+		sh.Unlock()
+	}
+
+	n := 0
+	for i, sh := range s.shards {
+		sh.record(removed[i])
+		sh.notify(removed[i])
+		s.bus.publish(entryKeys(removed[i])...)
+		n += len(removed[i])
+	}
+	return n
+}
+
+func (s *ShardedBCache[K, V]) Get(k K) (V, bool) {
+	v, _, ok := s.shard(k).get(k)
+	return v, ok
+}
+
+func (s *ShardedBCache[K, V]) GetWithExpire(k K) (V, time.Time, bool) {
+	return s.shard(k).get(k)
+}
+
+func (s *ShardedBCache[K, V]) GetOrLoad(ctx context.Context, k K, loader Loader[K, V]) (V, error) {
+	return s.shard(k).getOrLoad(ctx, k, loader)
+}
+
+func (s *ShardedBCache[K, V]) Touch(k K, d time.Duration) bool {
+	return s.shard(k).touch(k, d)
+}
+
+func (s *ShardedBCache[K, V]) TTL(k K) (time.Duration, bool) {
+	return s.shard(k).ttl(k)
+}
+
+func (s *ShardedBCache[K, V]) Persist(k K) bool {
+	return s.shard(k).persist(k)
+}
+
+func (s *ShardedBCache[K, V]) Count() int {
+	n := 0
+	for _, sh := range s.shards {
+		n += sh.count()
+	}
+	return n
+}
+
+func (s *ShardedBCache[K, V]) Cost() int64 {
+	var n int64
+	for _, sh := range s.shards {
+		n += sh.cost()
+	}
+	return n
+}
+
+// Stats 获取所有分片合并后的统计快照
+func (s *ShardedBCache[K, V]) Stats() Stats {
+	var st Stats
+	for _, sh := range s.shards {
+		st = st.merge(sh.stats.snapshot())
+	}
+	return st
+}
+
+// ResetStats 重置所有分片的统计
+func (s *ShardedBCache[K, V]) ResetStats() {
+	for _, sh := range s.shards {
+		sh.stats.reset()
+	}
+}
+
+func (s *ShardedBCache[K, V]) Clear() {
+	s.clear()
+	s.bus.publishClear()
+}
+
+func (s *ShardedBCache[K, V]) clear() {
+	for _, sh := range s.shards {
+		sh.clear()
+	}
+}
+
+// Close 停止哨兵协程并等待退出, 取消失效广播的订阅以及进行中的加载和后台刷新, 可以重复调用
+func (s *ShardedBCache[K, V]) Close() {
+	s.lc.close()
+	for _, shard := range s.shards {
+		shard.cancel()
+	}
+	s.bus.close()
+}
+
+func (s *ShardedBCache[K, V]) SaveTo(w io.Writer) error {
+	c := s.shards[0].config
+	sw := newSnapshotWriter(w, c.keyCodec, c.valueCodec)
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	for _, sh := range s.shards {
+		if err := sh.dump(sw); err != nil {
+			return err
+		}
+	}
+	return sw.close()
+}
+
+// LoadFrom 从 SaveTo 导出的快照加载, 完整的快照读入内存并校验通过后按分片写入
+func (s *ShardedBCache[K, V]) LoadFrom(r io.Reader) error {
+	c := s.shards[0].config
+	sr := newSnapshotReader(r, c.keyCodec, c.valueCodec)
+	mps, err := readSnapshot(sr, len(s.shards), func(k K) int {
+		return int(s.hasher(k) & s.mask)
+	})
+	if err != nil {
+		return err
+	}
+	for i, mp := range mps {
+		if mp != nil {
+			s.shards[i].load(mp)
+		}
+	}
+	return nil
+}
+
+func (s *ShardedBCache[K, V]) Load(data []byte) error {
+	return s.Unmarshal(data)
+}
+
+func (s *ShardedBCache[K, V]) Export() ([]byte, error) {
+	return s.Marshal()
+}
+
+func (s *ShardedBCache[K, V]) Marshal() ([]byte, error) {
+	mp := make(map[K]Iterator[V])
+	for _, sh := range s.shards {
+		for k, v := range sh.snapshot() {
+			mp[k] = v
+		}
+	}
+	return bmap.NewUnsafeAnyBMapByMap(mp).Marshal()
+}
+
+func (s *ShardedBCache[K, V]) Unmarshal(data []byte) error {
+	mp := bmap.NewUnsafeAnyBMap[K, Iterator[V]]()
+	err := mp.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	parts := make([]map[K]Iterator[V], len(s.shards))
+	mp.ForEach(func(k K, v Iterator[V]) {
+		i := s.hasher(k) & s.mask
+		if parts[i] == nil {
+			parts[i] = make(map[K]Iterator[V])
+		}
+		parts[i][k] = v
+	})
+	for i, part := range parts {
+		if part != nil {
+			s.shards[i].load(part)
+		}
+	}
+	return nil
+}
+
+func (s *ShardedBCache[K, V]) deleteExpire() {
+	for _, sh := range s.shards {
+		sh.deleteExpire()
+	}
+}
@@ -0,0 +1,161 @@
+package bcache
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+	"github.com/songzhibin97/go-baseutils/internal/keyhash"
+)
+
+func TestShardedCache(t *testing.T) {
+	tc := NewSharded[string, int](bcomparator.StringComparator(), 5)
+	assert.Len(t, tc.shards, 8)
+
+	for i := 0; i < 100; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpire)
+	}
+	assert.Equal(t, 100, tc.Count())
+	for i := 0; i < 100; i++ {
+		v, ok := tc.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	for _, sh := range tc.shards {
+		assert.NotZero(t, sh.count())
+	}
+
+	assert.False(t, tc.SetIfAbsent("1", 0, DefaultExpire))
+	assert.True(t, tc.Replace("1", 0, DefaultExpire))
+	v, _ := tc.Get("1")
+	assert.Equal(t, 0, v)
+
+	tc.Delete("1")
+	_, ok := tc.Get("1")
+	assert.False(t, ok)
+
+	tc.Set("expired", 1, time.Millisecond)
+	<-time.After(2 * time.Millisecond)
+	_, ok = tc.Get("expired")
+	assert.False(t, ok)
+
+	data, err := tc.Marshal()
+	assert.NoError(t, err)
+	oc := NewSharded[string, int](bcomparator.StringComparator(), 2)
+	assert.NoError(t, oc.Load(data))
+	assert.Equal(t, 99, oc.Count())
+	v, ok = oc.Get("99")
+	assert.True(t, ok)
+	assert.Equal(t, 99, v)
+
+	tc.Clear()
+	assert.Equal(t, 0, tc.Count())
+}
+
+func TestShardedCacheMaxEntries(t *testing.T) {
+	tc := NewSharded[int, int](bcomparator.IntComparator(), 4,
+		SetMaxEntries[int, int](8),
+		SetCapture[int, int](nil),
+	)
+	for i := 0; i < 100; i++ {
+		tc.Set(i, i, DefaultExpire)
+	}
+	assert.LessOrEqual(t, tc.Count(), 8)
+	for _, sh := range tc.shards {
+		assert.LessOrEqual(t, sh.count(), 2)
+	}
+
+	// 分片数量超过上限时减少分片, 总量不超过上限
+	for _, limit := range []int{1, 3, 4, 10} {
+		tc = NewSharded[int, int](bcomparator.IntComparator(), 16,
+			SetMaxEntries[int, int](limit),
+			SetCapture[int, int](nil),
+		)
+		assert.LessOrEqual(t, len(tc.shards), limit)
+		for i := 0; i < 100; i++ {
+			tc.Set(i, i, DefaultExpire)
+			assert.LessOrEqual(t, tc.Count(), limit)
+		}
+	}
+}
+
+func TestShardedCacheMaxCost(t *testing.T) {
+	tc := NewSharded[int, int](bcomparator.IntComparator(), 4,
+		SetMaxCost[int, int](10),
+		SetCostFunc[int, int](func(k int, v int) int64 { return int64(v) }),
+		SetCapture[int, int](nil),
+	)
+	var sum int64
+	for _, sh := range tc.shards {
+		sum += sh.config.maxCost
+	}
+	assert.Equal(t, int64(10), sum)
+	for i := 0; i < 100; i++ {
+		tc.Set(i, i%3+1, DefaultExpire)
+		assert.LessOrEqual(t, tc.Cost(), int64(10))
+	}
+
+	tc = NewSharded[int, int](bcomparator.IntComparator(), 16,
+		SetMaxCost[int, int](3),
+		SetCapture[int, int](nil),
+	)
+	assert.LessOrEqual(t, len(tc.shards), 3)
+	for i := 0; i < 100; i++ {
+		tc.Set(i, i, DefaultExpire)
+		assert.LessOrEqual(t, tc.Cost(), int64(3))
+	}
+}
+
+func TestShardedCacheConcurrent(t *testing.T) {
+	tc := NewSharded[int, int](bcomparator.IntComparator(), 16)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				tc.Set(i*1000+j, j, DefaultExpire)
+				tc.Get(i*1000 + j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 8000, tc.Count())
+}
+
+func BenchmarkShardedCacheGetConcurrent(b *testing.B) {
+	b.StopTimer()
+	tc := NewSharded[string, string](bcomparator.StringComparator(), 32)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		tc.Set(keys[i], "bar", DefaultExpire)
+	}
+	b.StartTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			tc.Get(keys[i&1023])
+			i++
+		}
+	})
+}
+
+func TestShardedCacheFloatKey(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	h := keyhash.Default[float64]()
+	assert.Equal(t, h(0), h(negZero))
+	assert.Equal(t, h(math.NaN()), h(-math.NaN()))
+	assert.Equal(t, keyhash.Default[float32]()(0), keyhash.Default[float32]()(float32(negZero)))
+
+	tc := NewSharded[float64, int](bcomparator.Float64Comparator(), 16)
+	tc.Set(0, 1, NoExpire)
+	v, ok := tc.Get(negZero)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
@@ -0,0 +1,369 @@
+package bcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// 快照格式(v3):
+//
+//	header: magic(4) version(1)
+//	entry:  tagEntry(1) uvarint(len(key)) key uvarint(len(value)) value varint(expire) varint(ttl) flags(1)
+//	        uvarint(len(tags)) [uvarint(len(tag)) tag]...
+//	footer: tagEnd(1) uvarint(count) crc32(4, 大端序, 覆盖 footer 之前写入的所有字节以及 tagEnd count)
+//
+// GobCodec 在同一个快照内共享编码器, 类型描述只在第一条用到该类型的记录中写入, 记录需要按顺序解码
+const (
+	snapshotVersion byte = 3
+
+	tagEnd   byte = 0
+	tagEntry byte = 1
+
+	flagSliding byte = 1 << 0
+
+	// snapshotBatch 导出时每次加锁读取的条目数
+	snapshotBatch = 1024
+	// maxSnapshotField 单个key或value编码后的最大长度
+	maxSnapshotField = 1 << 30
+	// maxSnapshotTags 单个key的最大tag数量
+	maxSnapshotTags = 1 << 16
+)
+
+var snapshotMagic = [4]byte{'B', 'C', 'S', 'N'}
+
+var (
+	ErrSnapshotMagic    = errors.New("bcache: invalid snapshot magic")
+	ErrSnapshotVersion  = errors.New("bcache: unsupported snapshot version")
+	ErrSnapshotChecksum = errors.New("bcache: snapshot checksum mismatch")
+	ErrSnapshotCorrupt  = errors.New("bcache: corrupt snapshot")
+)
+
+// snapshotWriter 流式写入快照
+type snapshotWriter[K comparable, V any] struct {
+	w     *bufio.Writer
+	crc   hash.Hash32
+	out   io.Writer
+	key   Codec[K]
+	value Codec[V]
+	count uint64
+	buf   [binary.MaxVarintLen64]byte
+}
+
+func newSnapshotWriter[K comparable, V any](w io.Writer, key Codec[K], value Codec[V]) *snapshotWriter[K, V] {
+	sw := &snapshotWriter[K, V]{
+		crc:   crc32.NewIEEE(),
+		key:   withSession(key),
+		value: withSession(value),
+	}
+	sw.w = bufio.NewWriter(w)
+	sw.out = io.MultiWriter(sw.w, sw.crc)
+	return sw
+}
+
+func (sw *snapshotWriter[K, V]) writeHeader() error {
+	_, err := sw.out.Write(append(snapshotMagic[:], snapshotVersion))
+	return err
+}
+
+func (sw *snapshotWriter[K, V]) writeEntry(k K, iter Iterator[V]) error {
+	kb, err := sw.key.Encode(k)
+	if err != nil {
+		return fmt.Errorf("bcache: encode key %v: %w", k, err)
+	}
+	vb, err := sw.value.Encode(iter.Value)
+	if err != nil {
+		return fmt.Errorf("bcache: encode value of key %v: %w", k, err)
+	}
+	var flags byte
+	if iter.sliding {
+		flags |= flagSliding
+	}
+	if _, err = sw.out.Write([]byte{tagEntry}); err != nil {
+		return err
+	}
+	if err = sw.writeBytes(kb); err != nil {
+		return err
+	}
+	if err = sw.writeBytes(vb); err != nil {
+		return err
+	}
+	if _, err = sw.out.Write(sw.buf[:binary.PutVarint(sw.buf[:], iter.Expire)]); err != nil {
+		return err
+	}
+	if _, err = sw.out.Write(sw.buf[:binary.PutVarint(sw.buf[:], iter.ttl)]); err != nil {
+		return err
+	}
+	if _, err = sw.out.Write([]byte{flags}); err != nil {
+		return err
+	}
+	if _, err = sw.out.Write(sw.buf[:binary.PutUvarint(sw.buf[:], uint64(len(iter.tags)))]); err != nil {
+		return err
+	}
+	for _, tag := range iter.tags {
+		if err = sw.writeBytes([]byte(tag)); err != nil {
+			return err
+		}
+	}
+	sw.count++
+	return nil
+}
+
+func (sw *snapshotWriter[K, V]) writeBytes(b []byte) error {
+	if _, err := sw.out.Write(sw.buf[:binary.PutUvarint(sw.buf[:], uint64(len(b)))]); err != nil {
+		return err
+	}
+	_, err := sw.out.Write(b)
+	return err
+}
+
+func (sw *snapshotWriter[K, V]) close() error {
+	if _, err := sw.out.Write([]byte{tagEnd}); err != nil {
+		return err
+	}
+	if _, err := sw.out.Write(sw.buf[:binary.PutUvarint(sw.buf[:], sw.count)]); err != nil {
+		return err
+	}
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], sw.crc.Sum32())
+	if _, err := sw.w.Write(sum[:]); err != nil {
+		return err
+	}
+	return sw.w.Flush()
+}
+
+// snapshotReader 流式读取快照
+type snapshotReader[K comparable, V any] struct {
+	r     *bufio.Reader
+	crc   hash.Hash32
+	key   Codec[K]
+	value Codec[V]
+	count uint64
+}
+
+func newSnapshotReader[K comparable, V any](r io.Reader, key Codec[K], value Codec[V]) *snapshotReader[K, V] {
+	return &snapshotReader[K, V]{
+		r:     bufio.NewReader(r),
+		crc:   crc32.NewIEEE(),
+		key:   withSession(key),
+		value: withSession(value),
+	}
+}
+
+// ReadByte 读取一个字节并计入校验和, 实现 io.ByteReader 供 binary.ReadUvarint 使用
+func (sr *snapshotReader[K, V]) ReadByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	sr.crc.Write([]byte{b})
+	return b, nil
+}
+
+func (sr *snapshotReader[K, V]) readFull(n uint64) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(sr.r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	sr.crc.Write(b)
+	return b, nil
+}
+
+func (sr *snapshotReader[K, V]) readHeader() error {
+	b, err := sr.readFull(uint64(len(snapshotMagic) + 1))
+	if err != nil {
+		return err
+	}
+	if [4]byte{b[0], b[1], b[2], b[3]} != snapshotMagic {
+		return ErrSnapshotMagic
+	}
+	if b[4] != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, b[4])
+	}
+	return nil
+}
+
+// next 读取下一条记录, 读到结尾时校验条目数以及校验和并返回 io.EOF
+func (sr *snapshotReader[K, V]) next() (K, Iterator[V], error) {
+	var (
+		k    K
+		iter Iterator[V]
+	)
+	tag, err := sr.ReadByte()
+	if err != nil {
+		return k, iter, err
+	}
+	switch tag {
+	case tagEnd:
+		return k, iter, sr.readFooter()
+	case tagEntry:
+	default:
+		return k, iter, fmt.Errorf("%w: unknown tag %d", ErrSnapshotCorrupt, tag)
+	}
+
+	kb, err := sr.readBytes()
+	if err != nil {
+		return k, iter, err
+	}
+	vb, err := sr.readBytes()
+	if err != nil {
+		return k, iter, err
+	}
+	if iter.Expire, err = binary.ReadVarint(sr); err != nil {
+		return k, iter, err
+	}
+	if iter.ttl, err = binary.ReadVarint(sr); err != nil {
+		return k, iter, err
+	}
+	flags, err := sr.ReadByte()
+	if err != nil {
+		return k, iter, err
+	}
+	iter.sliding = flags&flagSliding != 0
+	tags, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return k, iter, err
+	}
+	if tags > maxSnapshotTags {
+		return k, iter, fmt.Errorf("%w: %d tags too many", ErrSnapshotCorrupt, tags)
+	}
+	for i := uint64(0); i < tags; i++ {
+		tag, err := sr.readBytes()
+		if err != nil {
+			return k, iter, err
+		}
+		iter.tags = append(iter.tags, string(tag))
+	}
+
+	if k, err = sr.key.Decode(kb); err != nil {
+		return k, iter, fmt.Errorf("bcache: decode key: %w", err)
+	}
+	if iter.Value, err = sr.value.Decode(vb); err != nil {
+		return k, iter, fmt.Errorf("bcache: decode value of key %v: %w", k, err)
+	}
+	sr.count++
+	return k, iter, nil
+}
+
+func (sr *snapshotReader[K, V]) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxSnapshotField {
+		return nil, fmt.Errorf("%w: length %d too large", ErrSnapshotCorrupt, n)
+	}
+	return sr.readFull(n)
+}
+
+func (sr *snapshotReader[K, V]) readFooter() error {
+	count, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return err
+	}
+	if count != sr.count {
+		return fmt.Errorf("%w: expect %d entries got %d", ErrSnapshotCorrupt, count, sr.count)
+	}
+	expect := sr.crc.Sum32()
+	var sum [4]byte
+	if _, err = io.ReadFull(sr.r, sum[:]); err != nil {
+		return unexpectedEOF(err)
+	}
+	if binary.BigEndian.Uint32(sum[:]) != expect {
+		return ErrSnapshotChecksum
+	}
+	return io.EOF
+}
+
+// unexpectedEOF 快照中途结束属于数据损坏
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// dump 分批加锁读取kv写入快照, 不会在整个导出期间持有锁
+func (c *bCache[K, V]) dump(sw *snapshotWriter[K, V]) error {
+	c.RLock()
+	keys := c.member.Keys()
+	c.RUnlock()
+
+	type kv struct {
+		k    K
+		iter Iterator[V]
+	}
+	batch := make([]kv, 0, snapshotBatch)
+	for start := 0; start < len(keys); start += snapshotBatch {
+		end := start + snapshotBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch = batch[:0]
+		c.RLock()
+		now := c.now()
+		for _, k := range keys[start:end] {
+			if iter, ok := c.member.Get(k); ok && !c.expired(iter, now) {
+				batch = append(batch, kv{k: k, iter: iter})
+			}
+		}
+		c.RUnlock()
+
+		for _, e := range batch {
+			if err := sw.writeEntry(e.k, e.iter); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readSnapshot 读取完整的快照, 校验通过后按照 route 分组返回
+// 校验和位于快照末尾, 为了保证损坏的快照不会写入缓存, 需要先将完整的快照读入内存
+func readSnapshot[K comparable, V any](sr *snapshotReader[K, V], parts int, route func(k K) int) ([]map[K]Iterator[V], error) {
+	if err := sr.readHeader(); err != nil {
+		return nil, err
+	}
+	mps := make([]map[K]Iterator[V], parts)
+	for {
+		k, iter, err := sr.next()
+		if err == io.EOF {
+			return mps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		i := route(k)
+		if mps[i] == nil {
+			mps[i] = make(map[K]Iterator[V])
+		}
+		mps[i][k] = iter
+	}
+}
+
+func (c *bCache[K, V]) saveTo(w io.Writer) error {
+	sw := newSnapshotWriter(w, c.config.keyCodec, c.config.valueCodec)
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	if err := c.dump(sw); err != nil {
+		return err
+	}
+	return sw.close()
+}
+
+func (c *bCache[K, V]) loadFrom(r io.Reader) error {
+	sr := newSnapshotReader(r, c.config.keyCodec, c.config.valueCodec)
+	mps, err := readSnapshot(sr, 1, func(K) int { return 0 })
+	if err != nil {
+		return err
+	}
+	if mps[0] != nil {
+		c.load(mps[0])
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package bcache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+type snapshotKey struct {
+	Tenant string
+	ID     int
+}
+
+func snapshotKeyComparator() bcomparator.Comparator[snapshotKey] {
+	return func(a, b snapshotKey) int {
+		if a.Tenant != b.Tenant {
+			return bcomparator.StringComparator()(a.Tenant, b.Tenant)
+		}
+		return a.ID - b.ID
+	}
+}
+
+func TestSaveToLoadFrom(t *testing.T) {
+	tc := New[snapshotKey, []string](snapshotKeyComparator())
+	for i := 0; i < 3000; i++ {
+		tc.Set(snapshotKey{"t", i}, []string{strconv.Itoa(i)}, NoExpire)
+	}
+	tc.Set(snapshotKey{"expire", 0}, nil, time.Hour)
+	tc.SetSliding(snapshotKey{"sliding", 0}, nil, time.Hour)
+	tc.Set(snapshotKey{"expired", 0}, nil, time.Millisecond)
+	<-time.After(2 * time.Millisecond)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tc.SaveTo(&buf))
+
+	oc := New[snapshotKey, []string](snapshotKeyComparator())
+	assert.NoError(t, oc.LoadFrom(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, 3002, oc.Count())
+	v, ok := oc.Get(snapshotKey{"t", 42})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"42"}, v)
+
+	_, expire, ok := oc.GetWithExpire(snapshotKey{"expire", 0})
+	assert.True(t, ok)
+	_, origin, _ := tc.GetWithExpire(snapshotKey{"expire", 0})
+	assert.Equal(t, origin, expire)
+
+	iter, _ := oc.member.Get(snapshotKey{"sliding", 0})
+	assert.True(t, iter.sliding)
+	assert.Equal(t, int64(time.Hour), iter.ttl)
+
+	_, ok = oc.Get(snapshotKey{"expired", 0})
+	assert.False(t, ok)
+
+	sc := NewSharded[snapshotKey, []string](snapshotKeyComparator(), 4)
+	assert.NoError(t, sc.LoadFrom(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, 3002, sc.Count())
+	buf.Reset()
+	assert.NoError(t, sc.SaveTo(&buf))
+	oc.Clear()
+	assert.NoError(t, oc.LoadFrom(&buf))
+	assert.Equal(t, 3002, oc.Count())
+}
+
+func TestLoadFromCorrupt(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+	tc.Set("a", 1, NoExpire)
+	tc.Set("b", 2, NoExpire)
+	var buf bytes.Buffer
+	assert.NoError(t, tc.SaveTo(&buf))
+	data := buf.Bytes()
+
+	oc := New[string, int](bcomparator.StringComparator())
+
+	bad := append([]byte(nil), data...)
+	bad[0] = 'X'
+	assert.ErrorIs(t, oc.LoadFrom(bytes.NewReader(bad)), ErrSnapshotMagic)
+
+	bad = append([]byte(nil), data...)
+	bad[4] = 99
+	assert.ErrorIs(t, oc.LoadFrom(bytes.NewReader(bad)), ErrSnapshotVersion)
+
+	bad = append([]byte(nil), data...)
+	bad[len(bad)-1] ^= 0xff
+	assert.ErrorIs(t, oc.LoadFrom(bytes.NewReader(bad)), ErrSnapshotChecksum)
+
+	err := oc.LoadFrom(bytes.NewReader(data[:len(data)/2]))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrSnapshotCorrupt), err)
+
+	assert.Equal(t, 0, oc.Count())
+	assert.NoError(t, oc.LoadFrom(bytes.NewReader(data)))
+	assert.Equal(t, 2, oc.Count())
+}
+
+func TestSnapshotSize(t *testing.T) {
+	tc := New[snapshotKey, []string](snapshotKeyComparator())
+	var single int
+	for i := 0; i < 1000; i++ {
+		k, v := snapshotKey{"t", i}, []string{strconv.Itoa(i)}
+		tc.Set(k, v, NoExpire)
+		kb, _ := GobCodec[snapshotKey]{}.Encode(k)
+		vb, _ := GobCodec[[]string]{}.Encode(v)
+		single += len(kb) + len(vb)
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, tc.SaveTo(&buf))
+	// 类型描述只写入一次
+	assert.Less(t, buf.Len(), single/2)
+
+	tc2 := New[snapshotKey, []string](snapshotKeyComparator())
+	assert.NoError(t, tc2.LoadFrom(&buf))
+	assert.Equal(t, 1000, tc2.Count())
+	v, ok := tc2.Get(snapshotKey{"t", 999})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"999"}, v)
+}
+
+func BenchmarkSaveTo(b *testing.B) {
+	tc := New[int, string](bcomparator.IntComparator())
+	for i := 0; i < 100000; i++ {
+		tc.Set(i, "bar", NoExpire)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = tc.SaveTo(io.Discard)
+	}
+}
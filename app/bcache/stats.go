@@ -0,0 +1,129 @@
+package bcache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats 缓存统计快照
+type Stats struct {
+	// Hits 命中次数
+	Hits uint64
+	// Misses 未命中次数
+	Misses uint64
+	// Sets 写入次数
+	Sets uint64
+	// Deletes 主动删除次数
+	Deletes uint64
+	// Expirations 过期移除次数
+	Expirations uint64
+	// Evictions 超出容量淘汰次数
+	Evictions uint64
+	// LoadSuccesses 加载成功次数
+	LoadSuccesses uint64
+	// LoadFailures 加载失败次数
+	LoadFailures uint64
+	// TotalLoadTime 加载总耗时
+	TotalLoadTime time.Duration
+	// AverageLoadLatency 平均加载耗时
+	AverageLoadLatency time.Duration
+}
+
+// HitRatio 命中率
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// merge 合并两份统计
+func (s Stats) merge(o Stats) Stats {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Sets += o.Sets
+	s.Deletes += o.Deletes
+	s.Expirations += o.Expirations
+	s.Evictions += o.Evictions
+	s.LoadSuccesses += o.LoadSuccesses
+	s.LoadFailures += o.LoadFailures
+	s.TotalLoadTime += o.TotalLoadTime
+	if loads := s.LoadSuccesses + s.LoadFailures; loads > 0 {
+		s.AverageLoadLatency = s.TotalLoadTime / time.Duration(loads)
+	}
+	return s
+}
+
+// stats 缓存统计计数器, 使用原子操作避免在热路径上加锁
+// 需要单独分配保证64位对齐
+type stats struct {
+	hits          uint64
+	misses        uint64
+	sets          uint64
+	deletes       uint64
+	expirations   uint64
+	evictions     uint64
+	loadSuccesses uint64
+	loadFailures  uint64
+	loadNanos     uint64
+}
+
+func (s *stats) hit() {
+	atomic.AddUint64(&s.hits, 1)
+}
+
+func (s *stats) miss() {
+	atomic.AddUint64(&s.misses, 1)
+}
+
+func (s *stats) set() {
+	atomic.AddUint64(&s.sets, 1)
+}
+
+func (s *stats) delete() {
+	atomic.AddUint64(&s.deletes, 1)
+}
+
+func (s *stats) expire(n int) {
+	atomic.AddUint64(&s.expirations, uint64(n))
+}
+
+func (s *stats) evict(n int) {
+	atomic.AddUint64(&s.evictions, uint64(n))
+}
+
+func (s *stats) load(d time.Duration, err error) {
+	if err != nil {
+		atomic.AddUint64(&s.loadFailures, 1)
+	} else {
+		atomic.AddUint64(&s.loadSuccesses, 1)
+	}
+	atomic.AddUint64(&s.loadNanos, uint64(d))
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{}.merge(Stats{
+		Hits:          atomic.LoadUint64(&s.hits),
+		Misses:        atomic.LoadUint64(&s.misses),
+		Sets:          atomic.LoadUint64(&s.sets),
+		Deletes:       atomic.LoadUint64(&s.deletes),
+		Expirations:   atomic.LoadUint64(&s.expirations),
+		Evictions:     atomic.LoadUint64(&s.evictions),
+		LoadSuccesses: atomic.LoadUint64(&s.loadSuccesses),
+		LoadFailures:  atomic.LoadUint64(&s.loadFailures),
+		TotalLoadTime: time.Duration(atomic.LoadUint64(&s.loadNanos)),
+	})
+}
+
+func (s *stats) reset() {
+	atomic.StoreUint64(&s.hits, 0)
+	atomic.StoreUint64(&s.misses, 0)
+	atomic.StoreUint64(&s.sets, 0)
+	atomic.StoreUint64(&s.deletes, 0)
+	atomic.StoreUint64(&s.expirations, 0)
+	atomic.StoreUint64(&s.evictions, 0)
+	atomic.StoreUint64(&s.loadSuccesses, 0)
+	atomic.StoreUint64(&s.loadFailures, 0)
+	atomic.StoreUint64(&s.loadNanos, 0)
+}
@@ -0,0 +1,66 @@
+package bcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestStats(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator(), SetMaxEntries[string, int](2), SetCapture[string, int](nil))
+	tc.Set("a", 1, DefaultExpire)
+	tc.Set("b", 2, time.Millisecond)
+	tc.Get("a")
+	tc.Get("c")
+	<-time.After(2 * time.Millisecond)
+	tc.Get("b")
+	tc.Set("c", 3, DefaultExpire)
+	tc.Set("d", 4, DefaultExpire)
+	tc.Delete("d")
+
+	_, _ = tc.GetOrLoad(context.Background(), "e", func(ctx context.Context, k string) (int, time.Duration, error) {
+		time.Sleep(time.Millisecond)
+		return 5, DefaultExpire, nil
+	})
+	_, _ = tc.GetOrLoad(context.Background(), "f", func(ctx context.Context, k string) (int, time.Duration, error) {
+		return 0, 0, errors.New("load failed")
+	})
+
+	st := tc.Stats()
+	assert.Equal(t, uint64(1), st.Hits)
+	assert.Equal(t, uint64(4), st.Misses)
+	assert.Equal(t, uint64(5), st.Sets)
+	assert.Equal(t, uint64(1), st.Deletes)
+	assert.Equal(t, uint64(1), st.Expirations)
+	assert.Equal(t, uint64(1), st.Evictions)
+	assert.Equal(t, uint64(1), st.LoadSuccesses)
+	assert.Equal(t, uint64(1), st.LoadFailures)
+	assert.GreaterOrEqual(t, st.TotalLoadTime, time.Millisecond)
+	assert.Equal(t, st.TotalLoadTime/2, st.AverageLoadLatency)
+	assert.Equal(t, 0.2, st.HitRatio())
+
+	tc.ResetStats()
+	assert.Equal(t, Stats{}, tc.Stats())
+}
+
+func TestShardedStats(t *testing.T) {
+	tc := NewSharded[int, int](bcomparator.IntComparator(), 4)
+	for i := 0; i < 10; i++ {
+		tc.Set(i, i, DefaultExpire)
+	}
+	for i := 0; i < 20; i++ {
+		tc.Get(i)
+	}
+	st := tc.Stats()
+	assert.Equal(t, uint64(10), st.Sets)
+	assert.Equal(t, uint64(10), st.Hits)
+	assert.Equal(t, uint64(10), st.Misses)
+
+	tc.ResetStats()
+	assert.Equal(t, Stats{}, tc.Stats())
+}
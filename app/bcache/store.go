@@ -0,0 +1,87 @@
+package bcache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store[int, any] = (*MemoryStore[int, any])(nil)
+
+// Store 二级缓存使用的存储
+// Set 的d大于0时d之后过期, 否则不过期
+// Get 返回剩余存活时间, 不过期返回 NoExpire, 不存在或已过期时ok为false
+type Store[K comparable, V any] interface {
+	Get(ctx context.Context, k K) (v V, ttl time.Duration, ok bool, err error)
+	Set(ctx context.Context, k K, v V, d time.Duration) error
+	Delete(ctx context.Context, k K) error
+}
+
+// storeExpire 根据d计算 Store 的过期时间, 0 不过期
+func storeExpire(now time.Time, d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return now.Add(d).UnixNano()
+}
+
+// storeTTL 根据过期时间计算剩余存活时间, 已过期返回false
+func storeTTL(now time.Time, expire int64) (time.Duration, bool) {
+	if expire == 0 {
+		return NoExpire, true
+	}
+	ttl := time.Duration(expire - now.UnixNano())
+	return ttl, ttl > 0
+}
+
+type memoryItem[V any] struct {
+	v      V
+	expire int64
+}
+
+// MemoryStore 基于map的 Store, 过期数据在 Get 时惰性删除, 主要用于测试
+type MemoryStore[K comparable, V any] struct {
+	sync.Mutex
+	items map[K]memoryItem[V]
+}
+
+func NewMemoryStore[K comparable, V any]() *MemoryStore[K, V] {
+	return &MemoryStore[K, V]{items: make(map[K]memoryItem[V])}
+}
+
+func (s *MemoryStore[K, V]) Get(_ context.Context, k K) (V, time.Duration, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	var zero V
+	item, ok := s.items[k]
+	if !ok {
+		return zero, 0, false, nil
+	}
+	ttl, ok := storeTTL(time.Now(), item.expire)
+	if !ok {
+		delete(s.items, k)
+		return zero, 0, false, nil
+	}
+	return item.v, ttl, true, nil
+}
+
+func (s *MemoryStore[K, V]) Set(_ context.Context, k K, v V, d time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+	s.items[k] = memoryItem[V]{v: v, expire: storeExpire(time.Now(), d)}
+	return nil
+}
+
+func (s *MemoryStore[K, V]) Delete(_ context.Context, k K) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.items, k)
+	return nil
+}
+
+// Len 返回存储的条目数, 包括尚未惰性删除的过期数据
+func (s *MemoryStore[K, V]) Len() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.items)
+}
@@ -0,0 +1,113 @@
+package bcache
+
+import "time"
+
+func (c *bCache[K, V]) setWithTags(k K, v V, d time.Duration, tags []string) {
+	iter := c.newIterator(k, v, d)
+	iter.tags = uniqueTags(tags)
+	c.setIterator(k, iter)
+}
+
+// uniqueTags 去除重复的tag
+func uniqueTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		ret = append(ret, tag)
+	}
+	return ret
+}
+
+// tag 将k加入tags的索引, 调用方需要持有锁
+func (c *bCache[K, V]) tag(k K, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	if c.tagged == nil {
+		c.tagged = make(map[string]map[K]struct{})
+	}
+	for _, tag := range tags {
+		keys, ok := c.tagged[tag]
+		if !ok {
+			keys = make(map[K]struct{})
+			c.tagged[tag] = keys
+		}
+		keys[k] = struct{}{}
+	}
+}
+
+// untag 将k从tags的索引中移除, 调用方需要持有锁
+func (c *bCache[K, V]) untag(k K, tags []string) {
+	for _, tag := range tags {
+		keys, ok := c.tagged[tag]
+		if !ok {
+			continue
+		}
+		delete(keys, k)
+		if len(keys) == 0 {
+			delete(c.tagged, tag)
+		}
+	}
+}
+
+// invalidateTag 删除带有tag的所有kv, 返回被删除的kv, 调用方需要持有锁
+func (c *bCache[K, V]) invalidateTag(tag string) []entry[K, V] {
+	keys, ok := c.tagged[tag]
+	if !ok {
+		return nil
+	}
+	removed := make([]entry[K, V], 0, len(keys))
+	now := c.now()
+	for k := range keys {
+		c.bumpLoad(k)
+		iter, ok := c.member.Get(k)
+		if !ok {
+			continue
+		}
+		reason := EvictDeleted
+		if c.expired(iter, now) {
+			reason = EvictExpired
+		}
+		c.delete(k)
+		removed = append(removed, entry[K, V]{k: k, v: iter.Value, reason: reason})
+	}
+	return removed
+}
+
+// removeTag 删除带有tag的所有kv并回调, 返回被删除的key
+func (c *bCache[K, V]) removeTag(tag string) []K {
+	c.Lock()
+	removed := c.invalidateTag(tag)
+	c.Unlock()
+
+	c.record(removed)
+	c.notify(removed)
+	return entryKeys(removed)
+}
+
+func entryKeys[K comparable, V any](entries []entry[K, V]) []K {
+	ks := make([]K, len(entries))
+	for i, e := range entries {
+		ks[i] = e.k
+	}
+	return ks
+}
+
+// record 按移除原因记录统计
+func (c *bCache[K, V]) record(removed []entry[K, V]) {
+	for _, e := range removed {
+		switch e.reason {
+		case EvictDeleted:
+			c.stats.delete()
+		case EvictExpired:
+			c.stats.expire(1)
+		}
+	}
+}
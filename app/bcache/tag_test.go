@@ -0,0 +1,97 @@
+package bcache
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	var removed []string
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetOnEvict[string, int](func(k string, v int, reason EvictReason) {
+			if reason == EvictDeleted {
+				removed = append(removed, k)
+			}
+		}),
+	)
+	tc.SetWithTags("a", 1, NoExpire, "tenant:1", "user")
+	tc.SetWithTags("b", 2, NoExpire, "tenant:1", "tenant:1")
+	tc.SetWithTags("c", 3, NoExpire, "tenant:2", "user")
+	tc.Set("d", 4, NoExpire)
+
+	assert.Equal(t, 2, tc.InvalidateTag("tenant:1"))
+	sort.Strings(removed)
+	assert.Equal(t, []string{"a", "b"}, removed)
+	assert.Equal(t, 2, tc.Count())
+	assert.Equal(t, 0, tc.InvalidateTag("tenant:1"))
+	assert.Equal(t, uint64(2), tc.Stats().Deletes)
+
+	// 重新 Set 覆盖原有的tag
+	tc.Set("c", 3, NoExpire)
+	assert.Equal(t, 0, tc.InvalidateTag("user"))
+	assert.Equal(t, 0, tc.InvalidateTag("tenant:2"))
+
+	tc.SetWithTags("e", 5, NoExpire, "tenant:3")
+	tc.Delete("e")
+	assert.Empty(t, tc.tagged)
+
+	tc.SetWithTags("e", 5, NoExpire, "tenant:3")
+	tc.Clear()
+	assert.Empty(t, tc.tagged)
+	assert.Equal(t, 0, tc.InvalidateTag("tenant:3"))
+}
+
+func TestInvalidateTagExpired(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator(), SetInternal[string, int](5*time.Millisecond))
+	defer tc.Close()
+	tc.SetWithTags("a", 1, time.Millisecond, "tag")
+	<-time.After(20 * time.Millisecond)
+	tc.RLock()
+	assert.Empty(t, tc.tagged)
+	tc.RUnlock()
+}
+
+func TestShardedInvalidateTag(t *testing.T) {
+	tc := NewSharded[int, int](bcomparator.IntComparator(), 8)
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			tc.SetWithTags(i, i, NoExpire, "even")
+		} else {
+			tc.SetWithTags(i, i, NoExpire, "odd")
+		}
+	}
+	assert.Equal(t, 50, tc.InvalidateTag("even"))
+	assert.Equal(t, 50, tc.Count())
+	_, ok := tc.Get(2)
+	assert.False(t, ok)
+	_, ok = tc.Get(3)
+	assert.True(t, ok)
+}
+
+func TestTagSnapshot(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+	tc.SetWithTags("a", 1, NoExpire, "user", "hot")
+	tc.SetWithTags("b", 2, time.Hour, "user")
+	tc.Set("c", 3, NoExpire)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tc.SaveTo(&buf))
+	data := buf.Bytes()
+
+	loaded := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+	assert.NoError(t, loaded.LoadFrom(bytes.NewReader(data)))
+	assert.Equal(t, 1, loaded.InvalidateTag("hot"))
+	assert.Equal(t, 1, loaded.InvalidateTag("user"))
+	assert.Equal(t, 1, loaded.Count())
+
+	sharded := NewSharded[string, int](bcomparator.StringComparator(), 4, SetCapture[string, int](nil))
+	assert.NoError(t, sharded.LoadFrom(bytes.NewReader(data)))
+	assert.Equal(t, 2, sharded.InvalidateTag("user"))
+	assert.Equal(t, 1, sharded.Count())
+}
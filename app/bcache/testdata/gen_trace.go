@@ -0,0 +1,73 @@
+//go:build ignore
+// +build ignore
+
+// gen_trace 生成 zipf_scan.trace.gz: 固定种子的 zipf 访问序列中周期性插入一次性扫描
+// 用法: go run testdata/gen_trace.go testdata/zipf_scan.trace.gz
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"math/rand"
+	"os"
+)
+
+const (
+	// seed 随机种子, 固定后生成的序列是确定的
+	seed = 1
+	// zipfS zipfV zipfMax zipf 分布的参数, key 的范围为 [0, zipfMax]
+	zipfS   = 1.05
+	zipfV   = 1
+	zipfMax = 20000
+	// requests zipf 访问的次数
+	requests = 60000
+	// scanEvery scanAt 每 scanEvery 次访问中的第 scanAt 次之前插入一次扫描
+	scanEvery = 6000
+	scanAt    = 3000
+	// scanLen 每次扫描访问的key数量, 扫描的key从 1<<32 开始递增, 不会与 zipf 的key重复
+	scanLen = 1500
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: go run gen_trace.go <output>")
+		os.Exit(2)
+	}
+	if err := generate(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generate(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(gz)
+
+	z := rand.NewZipf(rand.New(rand.NewSource(seed)), zipfS, zipfV, zipfMax)
+	scan := uint64(1 << 32)
+	for i := 0; i < requests; i++ {
+		if i%scanEvery == scanAt {
+			for j := 0; j < scanLen; j++ {
+				fmt.Fprintln(w, scan)
+				scan++
+			}
+		}
+		fmt.Fprintln(w, z.Uint64())
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
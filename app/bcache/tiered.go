@@ -0,0 +1,244 @@
+package bcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/base/options"
+)
+
+var ErrNotFound = errors.New("bcache: not found")
+
+// pendingOp 等待写回二级存储的操作
+type pendingOp[V any] struct {
+	v V
+	// expire 过期时间 0 不过期
+	expire  int64
+	deleted bool
+}
+
+// TieredBCache 二级缓存, 一级为内存缓存, 二级为可插拔的 Store
+// 读取时先读一级缓存, 未命中时读取二级存储并回填一级缓存, 相同key的并发回源只执行一次
+// 写入默认同时写入两级(write-through), 开启 SetWriteBehind 后先写一级缓存, 再由后台协程批量写回二级存储
+type TieredBCache[K comparable, V any] struct {
+	config *TieredConfig[K, V]
+
+	l1 Cache[K, V]
+	l2 Store[K, V]
+
+	sync.Mutex // Protection pending&flushing&closed
+
+	// pending 等待写回的操作, 相同key只保留最后一次
+	pending map[K]pendingOp[V]
+	// flushing 正在写回的操作, 写回期间仍需要对读取可见
+	flushing map[K]pendingOp[V]
+	closed   bool
+
+	// flushMu 保证同一时刻只有一个写回
+	flushMu sync.Mutex
+
+	lc *lifecycle
+}
+
+// NewTiered 初始化二级缓存, l1 的生命周期由调用方管理
+func NewTiered[K comparable, V any](l1 Cache[K, V], l2 Store[K, V], opts ...options.Option[*TieredConfig[K, V]]) *TieredBCache[K, V] {
+	c := &TieredConfig[K, V]{}
+	for _, option := range opts {
+		option(c)
+	}
+	t := &TieredBCache[K, V]{
+		config: c,
+		l1:     l1,
+		l2:     l2,
+		lc:     &lifecycle{},
+	}
+	var interval time.Duration
+	if c.writeBehind {
+		interval = c.flushInterval
+	}
+	t.lc.start(interval, t.flushBackground, nil, realClock{})
+	return t
+}
+
+// Get 依次读取一级缓存以及二级存储, 都不存在时返回 ErrNotFound
+func (t *TieredBCache[K, V]) Get(ctx context.Context, k K) (V, error) {
+	return t.l1.GetOrLoad(ctx, k, t.load)
+}
+
+// load 回源读取二级存储, 尚未写回的操作优先
+func (t *TieredBCache[K, V]) load(ctx context.Context, k K) (V, time.Duration, error) {
+	var zero V
+	if op, ok := t.lookupPending(k); ok {
+		if op.deleted {
+			return zero, 0, ErrNotFound
+		}
+		ttl, ok := storeTTL(time.Now(), op.expire)
+		if !ok {
+			return zero, 0, ErrNotFound
+		}
+		return op.v, t.fill(ttl), nil
+	}
+	v, ttl, ok, err := t.l2.Get(ctx, k)
+	if err != nil {
+		return zero, 0, err
+	}
+	if !ok {
+		return zero, 0, ErrNotFound
+	}
+	return v, t.fill(ttl), nil
+}
+
+// fill 计算回填一级缓存的过期时间, 不超过 fillExpire
+func (t *TieredBCache[K, V]) fill(d time.Duration) time.Duration {
+	if d <= 0 {
+		d = NoExpire
+	}
+	if t.config.fillExpire > 0 && (d == NoExpire || d > t.config.fillExpire) {
+		return t.config.fillExpire
+	}
+	return d
+}
+
+func (t *TieredBCache[K, V]) lookupPending(k K) (pendingOp[V], bool) {
+	t.Lock()
+	defer t.Unlock()
+	if op, ok := t.pending[k]; ok {
+		return op, true
+	}
+	op, ok := t.flushing[k]
+	return op, ok
+}
+
+// Set 写入kv, d大于0时d之后过期, 否则不过期
+func (t *TieredBCache[K, V]) Set(ctx context.Context, k K, v V, d time.Duration) error {
+	if !t.writeBehind() {
+		if err := t.l2.Set(ctx, k, v, d); err != nil {
+			return err
+		}
+		t.l1.Set(k, v, t.fill(d))
+		return nil
+	}
+	t.l1.Set(k, v, t.fill(d))
+	return t.enqueue(ctx, k, pendingOp[V]{v: v, expire: storeExpire(time.Now(), d)})
+}
+
+// Delete 删除k
+func (t *TieredBCache[K, V]) Delete(ctx context.Context, k K) error {
+	if !t.writeBehind() {
+		if err := t.l2.Delete(ctx, k); err != nil {
+			return err
+		}
+		t.l1.Delete(k)
+		return nil
+	}
+	t.l1.Delete(k)
+	return t.enqueue(ctx, k, pendingOp[V]{deleted: true})
+}
+
+// writeBehind 是否延迟写回, 关闭后退化为 write-through
+func (t *TieredBCache[K, V]) writeBehind() bool {
+	if !t.config.writeBehind {
+		return false
+	}
+	t.Lock()
+	defer t.Unlock()
+	return !t.closed
+}
+
+// enqueue 记录等待写回的操作, 达到 flushBatch 时同步写回
+func (t *TieredBCache[K, V]) enqueue(ctx context.Context, k K, op pendingOp[V]) error {
+	t.Lock()
+	if t.pending == nil {
+		t.pending = make(map[K]pendingOp[V])
+	}
+	t.pending[k] = op
+	full := t.config.flushBatch > 0 && len(t.pending) >= t.config.flushBatch
+	t.Unlock()
+
+	if full {
+		return t.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush 将等待写回的操作写入二级存储, 失败的操作会保留到下一次写回
+func (t *TieredBCache[K, V]) Flush(ctx context.Context) error {
+	t.flushMu.Lock()
+	defer t.flushMu.Unlock()
+
+	t.Lock()
+	ops := t.pending
+	t.pending = nil
+	t.flushing = ops
+	t.Unlock()
+	if len(ops) == 0 {
+		return nil
+	}
+
+	var first error
+	failed := make(map[K]pendingOp[V])
+	now := time.Now()
+	for k, op := range ops {
+		var err error
+		if op.deleted {
+			err = t.l2.Delete(ctx, k)
+		} else if ttl, ok := storeTTL(now, op.expire); ok {
+			if ttl == NoExpire {
+				ttl = 0
+			}
+			err = t.l2.Set(ctx, k, op.v, ttl)
+		} else {
+			err = t.l2.Delete(ctx, k)
+		}
+		if err != nil {
+			failed[k] = op
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	t.Lock()
+	for k, op := range failed {
+		if _, ok := t.pending[k]; ok {
+			// 写回期间有新的操作
+			continue
+		}
+		if t.pending == nil {
+			t.pending = make(map[K]pendingOp[V])
+		}
+		t.pending[k] = op
+	}
+	t.flushing = nil
+	t.Unlock()
+
+	if first != nil {
+		return fmt.Errorf("bcache: flush %d of %d failed: %w", len(failed), len(ops), first)
+	}
+	return nil
+}
+
+func (t *TieredBCache[K, V]) flushBackground() {
+	if err := t.Flush(context.Background()); err != nil && t.config.onFlushError != nil {
+		t.config.onFlushError(err)
+	}
+}
+
+// Pending 返回等待写回的操作数
+func (t *TieredBCache[K, V]) Pending() int {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.pending)
+}
+
+// Close 停止后台写回协程并写回所有等待的操作, 关闭后写入退化为 write-through
+func (t *TieredBCache[K, V]) Close() error {
+	t.Lock()
+	t.closed = true
+	t.Unlock()
+	t.lc.close()
+	return t.Flush(context.Background())
+}
@@ -0,0 +1,214 @@
+package bcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestTieredWriteThrough(t *testing.T) {
+	ctx := context.Background()
+	l1 := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+	l2 := NewMemoryStore[string, int]()
+	tc := NewTiered[string, int](l1, l2, SetFillExpire[string, int](time.Minute))
+	defer tc.Close()
+
+	assert.NoError(t, tc.Set(ctx, "a", 1, NoExpire))
+	v, _, ok, _ := l2.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	ttl, ok := l1.TTL("a")
+	assert.True(t, ok)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	// 二级存储回填一级缓存
+	assert.NoError(t, l2.Set(ctx, "b", 2, time.Hour))
+	v, err := tc.Get(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+	v, ok = l1.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	_, err = tc.Get(ctx, "c")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, tc.Delete(ctx, "a"))
+	_, ok = l1.Get("a")
+	assert.False(t, ok)
+	_, _, ok, _ = l2.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+type failStore[K comparable, V any] struct {
+	*MemoryStore[K, V]
+	fail bool
+}
+
+func (s *failStore[K, V]) Set(ctx context.Context, k K, v V, d time.Duration) error {
+	if s.fail {
+		return errors.New("store unavailable")
+	}
+	return s.MemoryStore.Set(ctx, k, v, d)
+}
+
+func TestTieredWriteBehind(t *testing.T) {
+	ctx := context.Background()
+	l1 := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+	l2 := &failStore[string, int]{MemoryStore: NewMemoryStore[string, int]()}
+	tc := NewTiered[string, int](l1, l2, SetWriteBehind[string, int](time.Hour, 3))
+
+	assert.NoError(t, tc.Set(ctx, "a", 1, NoExpire))
+	assert.NoError(t, tc.Set(ctx, "b", 2, time.Hour))
+	assert.Equal(t, 0, l2.Len())
+	assert.Equal(t, 2, tc.Pending())
+
+	// 尚未写回时一级缓存被删除, 仍然可以读取到等待写回的值
+	l1.Delete("a")
+	v, err := tc.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	// 达到 batch 同步写回
+	assert.NoError(t, tc.Delete(ctx, "c"))
+	assert.Equal(t, 0, tc.Pending())
+	assert.Equal(t, 2, l2.Len())
+	_, ttl, ok, _ := l2.Get(ctx, "b")
+	assert.True(t, ok)
+	assert.True(t, ttl > 0 && ttl <= time.Hour)
+
+	// 写回失败的操作保留到下一次写回
+	l2.fail = true
+	assert.NoError(t, tc.Set(ctx, "d", 4, NoExpire))
+	assert.Error(t, tc.Flush(ctx))
+	assert.Equal(t, 1, tc.Pending())
+
+	l2.fail = false
+	assert.NoError(t, tc.Close())
+	assert.Equal(t, 0, tc.Pending())
+	v, _, ok, _ = l2.Get(ctx, "d")
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+
+	// 关闭后退化为 write-through
+	assert.NoError(t, tc.Set(ctx, "e", 5, NoExpire))
+	assert.Equal(t, 0, tc.Pending())
+	_, _, ok, _ = l2.Get(ctx, "e")
+	assert.True(t, ok)
+}
+
+func TestTieredWriteBehindInterval(t *testing.T) {
+	ctx := context.Background()
+	l1 := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+	l2 := NewMemoryStore[string, int]()
+	tc := NewTiered[string, int](l1, l2, SetWriteBehind[string, int](5*time.Millisecond, 0))
+	defer tc.Close()
+
+	assert.NoError(t, tc.Set(ctx, "a", 1, NoExpire))
+	assert.Eventually(t, func() bool {
+		return l2.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+// slowStore Get 读取后等待release再返回, 模拟回源期间的并发写入
+type slowStore[K comparable, V any] struct {
+	*MemoryStore[K, V]
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *slowStore[K, V]) Get(ctx context.Context, k K) (V, time.Duration, bool, error) {
+	v, ttl, ok, err := s.MemoryStore.Get(ctx, k)
+	s.started <- struct{}{}
+	<-s.release
+	return v, ttl, ok, err
+}
+
+func TestTieredConcurrentWrite(t *testing.T) {
+	ctx := context.Background()
+	for name, modify := range map[string]func(tc *TieredBCache[string, int]) error{
+		"delete": func(tc *TieredBCache[string, int]) error { return tc.Delete(ctx, "a") },
+		"set":    func(tc *TieredBCache[string, int]) error { return tc.Set(ctx, "a", 2, NoExpire) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			l1 := New[string, int](bcomparator.StringComparator(), SetCapture[string, int](nil))
+			l2 := &slowStore[string, int]{
+				MemoryStore: NewMemoryStore[string, int](),
+				started:     make(chan struct{}, 1),
+				release:     make(chan struct{}),
+			}
+			tc := NewTiered[string, int](l1, l2)
+			defer tc.Close()
+			assert.NoError(t, l2.MemoryStore.Set(ctx, "a", 1, NoExpire))
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				v, err := tc.Get(ctx, "a")
+				assert.NoError(t, err)
+				assert.Equal(t, 1, v)
+			}()
+			<-l2.started
+			assert.NoError(t, modify(tc))
+			close(l2.release)
+			<-done
+
+			// 回源期间的写入不会被回源的旧值覆盖
+			want, wantErr := 0, error(ErrNotFound)
+			if name == "set" {
+				want, wantErr = 2, nil
+			}
+			v, err := tc.Get(ctx, "a")
+			assert.Equal(t, wantErr, err)
+			assert.Equal(t, want, v)
+		})
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore[string, []int](dir, nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Set(ctx, "a", []int{1, 2}, NoExpire))
+	assert.NoError(t, s.Set(ctx, "b", []int{3}, time.Millisecond))
+	assert.NoError(t, s.Set(ctx, "c", []int{4}, time.Hour))
+
+	v, ttl, ok, err := s.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1, 2}, v)
+	assert.Equal(t, NoExpire, ttl)
+
+	<-time.After(2 * time.Millisecond)
+	_, _, ok, err = s.Get(ctx, "b")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	// 过期后重新写入
+	assert.NoError(t, s.Set(ctx, "b", []int{5}, NoExpire))
+	v, _, ok, err = s.Get(ctx, "b")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{5}, v)
+
+	// 重新打开目录后数据仍然存在
+	s, err = NewFileStore[string, []int](dir, nil, nil)
+	assert.NoError(t, err)
+	v, ttl, ok, err = s.Get(ctx, "c")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{4}, v)
+	assert.True(t, ttl > 0 && ttl <= time.Hour)
+
+	assert.NoError(t, s.Delete(ctx, "c"))
+	assert.NoError(t, s.Delete(ctx, "c"))
+	_, _, ok, err = s.Get(ctx, "c")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
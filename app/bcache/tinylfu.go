@@ -0,0 +1,251 @@
+package bcache
+
+import (
+	"container/list"
+
+	"github.com/songzhibin97/go-baseutils/internal/keyhash"
+)
+
+var _ Policy[int] = (*tinyLFUPolicy[int])(nil)
+
+const (
+	// sketchDepth count-min sketch 的行数
+	sketchDepth = 4
+	// sketchMax 计数器的上限
+	sketchMax = 15
+	// maxSketchWidth 每行计数器数量的上限, 频次估计最多占用 sketchDepth*maxSketchWidth 字节
+	maxSketchWidth = 1 << 22
+)
+
+// DefaultTinyLFUEntries SetTinyLFU 以及 SetMaxEntries 都没有设置条目数时 W-TinyLFU 预计的最大条目数
+const DefaultTinyLFUEntries = 1 << 16
+
+// sketch count-min sketch 频次估计, 计数器总和达到 sampleSize 后全部减半用于老化
+type sketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newSketch(capacity int) *sketch {
+	width := 16
+	for width < capacity && width < maxSketchWidth {
+		width <<= 1
+	}
+	s := &sketch{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// index 返回第i行的下标
+func (s *sketch) index(h uint64, i int) uint64 {
+	h1, h2 := h&0xffffffff, h>>32
+	return (h1 + uint64(i)*h2) & s.mask
+}
+
+func (s *sketch) increment(h uint64) {
+	added := false
+	for i := range s.rows {
+		idx := s.index(h, i)
+		if s.rows[i][idx] < sketchMax {
+			s.rows[i][idx]++
+			added = true
+		}
+	}
+	if added {
+		s.additions++
+		if s.additions >= s.sampleSize {
+			s.reset()
+		}
+	}
+}
+
+func (s *sketch) estimate(h uint64) uint8 {
+	min := uint8(sketchMax)
+	for i := range s.rows {
+		if c := s.rows[i][s.index(h, i)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// reset 老化, 所有计数器减半
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func (s *sketch) clear() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] = 0
+		}
+	}
+	s.additions = 0
+}
+
+// tinyLFU 所在的队列
+const (
+	segWindow = iota
+	segProbation
+	segProtected
+)
+
+type tinyLFUItem[K comparable] struct {
+	k    K
+	hash uint64
+	seg  int
+}
+
+// tinyLFUPolicy W-TinyLFU, 新写入的key先进入窗口 LRU, 窗口溢出的key与主缓存的淘汰候选比较频次, 频次低的被淘汰
+// 主缓存是 SLRU, 分为试用区与保护区, 试用区的key再次访问后晋升到保护区
+type tinyLFUPolicy[K comparable] struct {
+	hasher func(k K) uint64
+	sketch *sketch
+
+	window     *list.List
+	probation  *list.List
+	protected  *list.List
+	items      map[K]*list.Element
+	windowCap  int
+	mainCap    int
+	protectCap int
+}
+
+// NewTinyLFUPolicy W-TinyLFU 淘汰策略, capacity 为缓存的最大条目数, 用于确定窗口大小以及频次估计的精度
+// 一次性访问的key(例如扫描)无法挤掉频繁访问的key
+func NewTinyLFUPolicy[K comparable](capacity int) Policy[K] {
+	return newTinyLFUPolicy[K](capacity, keyhash.Default[K]())
+}
+
+func newTinyLFUPolicy[K comparable](capacity int, hasher func(k K) uint64) *tinyLFUPolicy[K] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	windowCap := capacity / 100
+	if windowCap < 1 {
+		windowCap = 1
+	}
+	mainCap := capacity - windowCap
+	return &tinyLFUPolicy[K]{
+		hasher:     hasher,
+		sketch:     newSketch(capacity),
+		window:     list.New(),
+		probation:  list.New(),
+		protected:  list.New(),
+		items:      make(map[K]*list.Element),
+		windowCap:  windowCap,
+		mainCap:    mainCap,
+		protectCap: mainCap * 8 / 10,
+	}
+}
+
+func (p *tinyLFUPolicy[K]) list(seg int) *list.List {
+	switch seg {
+	case segWindow:
+		return p.window
+	case segProbation:
+		return p.probation
+	default:
+		return p.protected
+	}
+}
+
+func (p *tinyLFUPolicy[K]) Add(k K) {
+	if _, ok := p.items[k]; ok {
+		p.Access(k)
+		return
+	}
+	item := &tinyLFUItem[K]{k: k, hash: p.hasher(k), seg: segWindow}
+	p.sketch.increment(item.hash)
+	p.items[k] = p.window.PushFront(item)
+	// 主缓存未满时窗口溢出的key直接进入试用区
+	for p.window.Len() > p.windowCap && p.probation.Len()+p.protected.Len() < p.mainCap {
+		p.move(p.window.Back(), segProbation)
+	}
+}
+
+func (p *tinyLFUPolicy[K]) Access(k K) {
+	e, ok := p.items[k]
+	if !ok {
+		return
+	}
+	item := e.Value.(*tinyLFUItem[K])
+	p.sketch.increment(item.hash)
+	switch item.seg {
+	case segWindow:
+		p.window.MoveToFront(e)
+	case segProbation:
+		p.move(e, segProtected)
+		for p.protected.Len() > p.protectCap {
+			p.move(p.protected.Back(), segProbation)
+		}
+	case segProtected:
+		p.protected.MoveToFront(e)
+	}
+}
+
+// move 将e移动到seg的队首
+func (p *tinyLFUPolicy[K]) move(e *list.Element, seg int) {
+	item := p.list(e.Value.(*tinyLFUItem[K]).seg).Remove(e).(*tinyLFUItem[K])
+	item.seg = seg
+	p.items[item.k] = p.list(seg).PushFront(item)
+}
+
+func (p *tinyLFUPolicy[K]) Remove(k K) {
+	if e, ok := p.items[k]; ok {
+		p.list(e.Value.(*tinyLFUItem[K]).seg).Remove(e)
+		delete(p.items, k)
+	}
+}
+
+// Evict 在写入新key之前调用, 窗口已满时窗口的淘汰候选与主缓存的淘汰候选比较频次, 否则淘汰主缓存的候选
+func (p *tinyLFUPolicy[K]) Evict() (K, bool) {
+	victim := p.probation.Back()
+	if victim == nil {
+		victim = p.protected.Back()
+	}
+	candidate := p.window.Back()
+	if candidate != nil && (victim == nil || p.window.Len() >= p.windowCap) {
+		if victim != nil {
+			ci := candidate.Value.(*tinyLFUItem[K])
+			vi := victim.Value.(*tinyLFUItem[K])
+			if p.sketch.estimate(ci.hash) > p.sketch.estimate(vi.hash) {
+				// 候选胜出进入试用区, 淘汰主缓存的候选
+				p.move(candidate, segProbation)
+				return p.evict(victim), true
+			}
+		}
+		return p.evict(candidate), true
+	}
+	if victim == nil {
+		var zero K
+		return zero, false
+	}
+	return p.evict(victim), true
+}
+
+func (p *tinyLFUPolicy[K]) evict(e *list.Element) K {
+	item := p.list(e.Value.(*tinyLFUItem[K]).seg).Remove(e).(*tinyLFUItem[K])
+	delete(p.items, item.k)
+	return item.k
+}
+
+func (p *tinyLFUPolicy[K]) Clear() {
+	p.window.Init()
+	p.probation.Init()
+	p.protected.Init()
+	p.items = make(map[K]*list.Element)
+	p.sketch.clear()
+}
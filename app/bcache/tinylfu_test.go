@@ -0,0 +1,163 @@
+package bcache
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+	"github.com/songzhibin97/go-baseutils/base/options"
+	"github.com/songzhibin97/go-baseutils/internal/keyhash"
+)
+
+func TestSketch(t *testing.T) {
+	h := keyhash.Default[int]()
+	s := newSketch(64)
+	for i := 0; i < 5; i++ {
+		s.increment(h(1))
+	}
+	s.increment(h(2))
+	assert.Equal(t, uint8(5), s.estimate(h(1)))
+	assert.True(t, s.estimate(h(2)) >= 1)
+	for i := 0; i < 20; i++ {
+		s.increment(h(1))
+	}
+	assert.Equal(t, uint8(sketchMax), s.estimate(h(1)))
+
+	s.reset()
+	assert.Equal(t, uint8(sketchMax/2), s.estimate(h(1)))
+	s.clear()
+	assert.Equal(t, uint8(0), s.estimate(h(1)))
+}
+
+func TestTinyLFUPolicy(t *testing.T) {
+	var evicted []string
+	tc := New[string, int](bcomparator.StringComparator(),
+		SetMaxEntries[string, int](3),
+		SetTinyLFU[string, int](0),
+		SetCapture[string, int](func(k string, v int) {
+			evicted = append(evicted, k)
+		}),
+	)
+	tc.Set("a", 1, NoExpire)
+	tc.Set("b", 2, NoExpire)
+	tc.Set("c", 3, NoExpire)
+	for i := 0; i < 3; i++ {
+		tc.Get("a")
+		tc.Get("b")
+	}
+	// 一次性的key无法挤掉频繁访问的key
+	for i := 0; i < 10; i++ {
+		tc.Set("scan"+strconv.Itoa(i), i, NoExpire)
+	}
+	assert.Equal(t, 3, tc.Count())
+	_, ok := tc.Get("a")
+	assert.True(t, ok)
+	_, ok = tc.Get("b")
+	assert.True(t, ok)
+	assert.Len(t, evicted, 10)
+
+	p := NewTinyLFUPolicy[int](10)
+	p.Add(1)
+	p.Add(2)
+	p.Remove(1)
+	assert.Equal(t, []int{2}, evictAll(p))
+	p.Add(1)
+	p.Clear()
+	assert.Empty(t, evictAll(p))
+}
+
+func TestTinyLFUSize(t *testing.T) {
+	// 只设置成本上限时不按照成本初始化频次估计
+	tc := New[string, []byte](bcomparator.StringComparator(),
+		SetMaxCost[string, []byte](1<<30),
+		SetCostFunc[string, []byte](func(k string, v []byte) int64 { return int64(len(v)) }),
+		SetTinyLFU[string, []byte](0),
+	)
+	assert.Len(t, tc.policy.(*tinyLFUPolicy[string]).sketch.rows[0], DefaultTinyLFUEntries)
+
+	tc = New[string, []byte](bcomparator.StringComparator(),
+		SetMaxCost[string, []byte](1<<30),
+		SetTinyLFU[string, []byte](1000),
+	)
+	assert.Len(t, tc.policy.(*tinyLFUPolicy[string]).sketch.rows[0], 1024)
+
+	assert.Len(t, newSketch(1 << 30).rows[0], maxSketchWidth)
+}
+
+//go:generate go run testdata/gen_trace.go testdata/zipf_scan.trace.gz
+
+// readTrace 读取 testdata 中记录的访问序列, 每行一个key
+// zipf_scan.trace.gz 由 testdata/gen_trace.go 生成: 固定种子的 zipf(s=1.05, 20001个key) 访问60000次, 每6000次插入一次1500个key的扫描
+func readTrace(tb testing.TB, name string) []uint64 {
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var keys []uint64
+	sc := bufio.NewScanner(gz)
+	for sc.Scan() {
+		k, err := strconv.ParseUint(sc.Text(), 10, 64)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+	if err = sc.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return keys
+}
+
+// hitRatio 按照访问序列回放, 未命中时写入, 返回命中率
+func hitRatio(keys []uint64, size int, opts ...options.Option[*Config[uint64, uint64]]) float64 {
+	opts = append(opts, SetMaxEntries[uint64, uint64](size), SetCapture[uint64, uint64](nil))
+	tc := New[uint64, uint64](bcomparator.Uint64Comparator(), opts...)
+	for _, k := range keys {
+		if _, ok := tc.Get(k); !ok {
+			tc.Set(k, k, NoExpire)
+		}
+	}
+	return tc.Stats().HitRatio()
+}
+
+func TestTinyLFUHitRatio(t *testing.T) {
+	keys := readTrace(t, "zipf_scan.trace.gz")
+	lru := hitRatio(keys, 1000)
+	tinyLFU := hitRatio(keys, 1000, SetTinyLFU[uint64, uint64](0))
+	t.Logf("lru: %.4f tinylfu: %.4f", lru, tinyLFU)
+	assert.Greater(t, tinyLFU, lru)
+}
+
+func BenchmarkHitRatio(b *testing.B) {
+	keys := readTrace(b, "zipf_scan.trace.gz")
+	policies := []struct {
+		name string
+		opts []options.Option[*Config[uint64, uint64]]
+	}{
+		{name: "LRU"},
+		{name: "LFU", opts: []options.Option[*Config[uint64, uint64]]{SetPolicy[uint64, uint64](NewLFUPolicy[uint64])}},
+		{name: "TinyLFU", opts: []options.Option[*Config[uint64, uint64]]{SetTinyLFU[uint64, uint64](0)}},
+	}
+	for _, size := range []int{250, 1000, 4000} {
+		for _, p := range policies {
+			b.Run(p.name+"/"+strconv.Itoa(size), func(b *testing.B) {
+				var ratio float64
+				for i := 0; i < b.N; i++ {
+					ratio = hitRatio(keys, size, p.opts...)
+				}
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
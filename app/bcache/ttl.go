@@ -0,0 +1,70 @@
+package bcache
+
+import "time"
+
+func (c *bCache[K, V]) setSliding(k K, v V, d time.Duration) {
+	iter := c.newIterator(k, v, d)
+	iter.sliding = true
+	c.setIterator(k, iter)
+}
+
+func (c *bCache[K, V]) touch(k K, d time.Duration) bool {
+	c.Lock()
+	defer c.Unlock()
+	iter, ok := c.alive(k)
+	if !ok {
+		return false
+	}
+	expire, ttl := c.expireOf(d)
+	iter.Expire, iter.ttl = expire, int64(ttl)
+	c.resetExpire(k, iter)
+	return true
+}
+
+func (c *bCache[K, V]) ttl(k K) (time.Duration, bool) {
+	c.Lock()
+	defer c.Unlock()
+	iter, ok := c.alive(k)
+	if !ok {
+		return 0, false
+	}
+	if !iter.isVisit() {
+		return NoExpire, true
+	}
+	if d := time.Duration(iter.Expire - c.now()); d > 0 {
+		return d, true
+	}
+	// 处于 staleExpire 期间
+	return 0, true
+}
+
+func (c *bCache[K, V]) persist(k K) bool {
+	c.Lock()
+	defer c.Unlock()
+	iter, ok := c.alive(k)
+	if !ok {
+		return false
+	}
+	iter.Expire, iter.ttl = 0, 0
+	c.resetExpire(k, iter)
+	return true
+}
+
+// alive 获取未过期的k, 调用方需要持有锁
+func (c *bCache[K, V]) alive(k K) (Iterator[V], bool) {
+	iter, ok := c.member.Get(k)
+	if !ok || c.expired(iter, c.now()) {
+		return iter, false
+	}
+	return iter, true
+}
+
+// resetExpire 更新k的过期时间并维护visit, 调用方需要持有锁
+func (c *bCache[K, V]) resetExpire(k K, iter Iterator[V]) {
+	if iter.Expire != 0 {
+		c.visit.add(k, c.deadline(iter))
+	} else {
+		c.visit.remove(k)
+	}
+	c.member.Put(k, iter)
+}
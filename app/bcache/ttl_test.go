@@ -0,0 +1,73 @@
+package bcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestSliding(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+	tc.SetSliding("a", 1, 30*time.Millisecond)
+	tc.Set("b", 1, 30*time.Millisecond)
+	for i := 0; i < 4; i++ {
+		<-time.After(15 * time.Millisecond)
+		_, ok := tc.Get("a")
+		assert.True(t, ok)
+	}
+	_, ok := tc.Get("b")
+	assert.False(t, ok)
+
+	<-time.After(40 * time.Millisecond)
+	_, ok = tc.Get("a")
+	assert.False(t, ok)
+}
+
+func TestTouchTTLPersist(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator())
+
+	_, ok := tc.TTL("a")
+	assert.False(t, ok)
+	assert.False(t, tc.Touch("a", time.Second))
+	assert.False(t, tc.Persist("a"))
+
+	tc.Set("a", 1, NoExpire)
+	d, ok := tc.TTL("a")
+	assert.True(t, ok)
+	assert.Equal(t, NoExpire, d)
+
+	assert.True(t, tc.Touch("a", 20*time.Millisecond))
+	d, ok = tc.TTL("a")
+	assert.True(t, ok)
+	assert.True(t, d > 0 && d <= 20*time.Millisecond)
+
+	assert.True(t, tc.Persist("a"))
+	d, _ = tc.TTL("a")
+	assert.Equal(t, NoExpire, d)
+	<-time.After(30 * time.Millisecond)
+	_, ok = tc.Get("a")
+	assert.True(t, ok)
+
+	assert.True(t, tc.Touch("a", 10*time.Millisecond))
+	<-time.After(20 * time.Millisecond)
+	_, ok = tc.Get("a")
+	assert.False(t, ok)
+	assert.False(t, tc.Touch("a", time.Second))
+}
+
+func TestTouchSentinel(t *testing.T) {
+	tc := New[string, int](bcomparator.StringComparator(), SetInternal[string, int](time.Millisecond))
+	defer tc.Close()
+	tc.Set("a", 1, 10*time.Millisecond)
+	assert.True(t, tc.Persist("a"))
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, 1, tc.Count())
+
+	assert.True(t, tc.Touch("a", 5*time.Millisecond))
+	assert.Eventually(t, func() bool {
+		return tc.Count() == 0
+	}, time.Second, time.Millisecond)
+}
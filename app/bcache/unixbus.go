@@ -0,0 +1,342 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package bcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var _ Bus = (*UnixBus)(nil)
+
+var ErrBusDisconnected = errors.New("bcache: bus disconnected")
+
+const (
+	// maxBusFrame 单条消息的最大长度
+	maxBusFrame = 1 << 24
+	// busWriteTimeout 写入单个连接的超时时间, 超时的连接会被关闭
+	busWriteTimeout = time.Second
+	// maxBusQueue 单个连接等待写入的消息数量上限, 超过时关闭连接, 客户端会重新加入
+	maxBusQueue = 4096
+	// busRetryMin busRetryMax 重新加入的退避时间
+	busRetryMin = 10 * time.Millisecond
+	busRetryMax = time.Second
+)
+
+// UnixBus 基于 Unix domain socket 的失效广播, 同一路径下的多个进程(或同一进程内的多个实例)共享消息
+// 持有 path+".lock" 文件锁的实例作为hub监听path并转发消息, 其余实例连接hub
+// hub退出后文件锁被释放, 其余实例重新竞争成为hub, 重新加入期间发布的消息只会投递到本地订阅
+// 每个连接由单独的协程写入, 发布只入队不会阻塞在慢的连接上
+type UnixBus struct {
+	path  string
+	local *ChannelBus
+
+	sync.Mutex // Protection conns&ln&lock&closed
+	conns      map[net.Conn]*busConn
+	ln         net.Listener
+	lock       *os.File
+	closed     bool
+
+	wg sync.WaitGroup
+}
+
+// NewUnixBus 加入path对应的失效广播, path 所在的目录需要可写
+func NewUnixBus(path string) (*UnixBus, error) {
+	b := &UnixBus{
+		path:  path,
+		local: NewChannelBus(64),
+		conns: make(map[net.Conn]*busConn),
+	}
+	var err error
+	for wait := busRetryMin; wait <= busRetryMax; wait *= 2 {
+		if err = b.join(); err == nil {
+			return b, nil
+		}
+		time.Sleep(wait)
+	}
+	_ = b.local.Close()
+	return nil, err
+}
+
+// join 竞争文件锁, 成功时成为hub, 否则连接hub
+func (b *UnixBus) join() error {
+	f, err := os.OpenFile(b.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		_ = f.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return err
+		}
+		return b.dial()
+	}
+
+	// 持有文件锁, 残留的socket文件可以安全删除
+	_ = os.Remove(b.path)
+	ln, err := net.Listen("unix", b.path)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	b.Lock()
+	defer b.Unlock()
+	if b.closed {
+		_ = ln.Close()
+		_ = f.Close()
+		return ErrBusClosed
+	}
+	b.ln, b.lock = ln, f
+	b.wg.Add(1)
+	go b.accept(ln)
+	return nil
+}
+
+func (b *UnixBus) dial() error {
+	conn, err := net.Dial("unix", b.path)
+	if err != nil {
+		return err
+	}
+	return b.serve(conn, false)
+}
+
+// serve 记录连接并启动读取以及写入协程
+func (b *UnixBus) serve(conn net.Conn, hub bool) error {
+	b.Lock()
+	defer b.Unlock()
+	if b.closed {
+		_ = conn.Close()
+		return ErrBusClosed
+	}
+	bc := &busConn{conn: conn, notify: make(chan struct{}, 1)}
+	b.conns[conn] = bc
+	b.wg.Add(2)
+	go b.read(bc, hub)
+	go func() {
+		defer b.wg.Done()
+		bc.run()
+	}()
+	return nil
+}
+
+func (b *UnixBus) accept(ln net.Listener) {
+	defer b.wg.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		if b.serve(conn, true) != nil {
+			return
+		}
+	}
+}
+
+// read 读取连接上的消息并投递到本地订阅, hub 还会转发给其他连接
+// 客户端与hub的连接断开后重新加入
+func (b *UnixBus) read(bc *busConn, hub bool) {
+	defer b.wg.Done()
+	conn := bc.conn
+	r := bufio.NewReader(conn)
+	for {
+		frame, err := readFrame(r)
+		if err != nil {
+			break
+		}
+		var msg Invalidation
+		if msg.unmarshal(frame) != nil {
+			break
+		}
+		_ = b.local.Publish(msg)
+		if hub {
+			b.broadcast(frame, conn)
+		}
+	}
+
+	b.Lock()
+	delete(b.conns, conn)
+	_ = conn.Close()
+	bc.close()
+	rejoin := !hub && !b.closed
+	if rejoin {
+		b.wg.Add(1)
+	}
+	b.Unlock()
+	if rejoin {
+		go b.rejoin()
+	}
+}
+
+func (b *UnixBus) rejoin() {
+	defer b.wg.Done()
+	wait := busRetryMin
+	for {
+		err := b.join()
+		if err == nil || errors.Is(err, ErrBusClosed) {
+			return
+		}
+		time.Sleep(wait)
+		if wait *= 2; wait > busRetryMax {
+			wait = busRetryMax
+		}
+	}
+}
+
+// broadcast 将消息加入除except以外的所有连接的写入队列, 返回入队的连接数量
+func (b *UnixBus) broadcast(frame []byte, except net.Conn) int {
+	b.Lock()
+	conns := make([]*busConn, 0, len(b.conns))
+	for conn, bc := range b.conns {
+		if conn != except {
+			conns = append(conns, bc)
+		}
+	}
+	b.Unlock()
+
+	n := 0
+	for _, bc := range conns {
+		if bc.push(frame) {
+			n++
+		}
+	}
+	return n
+}
+
+// Publish 投递到本地订阅并发送到其他实例, 客户端与hub断开时返回 ErrBusDisconnected
+func (b *UnixBus) Publish(msg Invalidation) error {
+	if err := b.local.Publish(msg); err != nil {
+		return err
+	}
+	b.Lock()
+	hub := b.ln != nil
+	b.Unlock()
+	if b.broadcast(msg.marshal(), nil) == 0 && !hub {
+		return ErrBusDisconnected
+	}
+	return nil
+}
+
+func (b *UnixBus) Subscribe(source string, fn func(msg Invalidation)) (func(), error) {
+	return b.local.Subscribe(source, fn)
+}
+
+// Close 退出广播, hub 退出时删除socket文件并释放文件锁
+func (b *UnixBus) Close() error {
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return nil
+	}
+	b.closed = true
+	if b.ln != nil {
+		_ = b.ln.Close()
+		_ = os.Remove(b.path)
+		_ = b.lock.Close()
+	}
+	for conn := range b.conns {
+		_ = conn.Close()
+	}
+	b.Unlock()
+
+	b.wg.Wait()
+	return b.local.Close()
+}
+
+// busConn 连接的写入队列, 由单独的协程依次写入, 写入失败或队列溢出时关闭连接, 读取协程会清理连接
+type busConn struct {
+	conn net.Conn
+
+	mu     sync.Mutex // Protection queue&closed
+	queue  [][]byte
+	closed bool
+
+	// notify 队列有新消息或关闭时写入
+	notify chan struct{}
+}
+
+// push 加入写入队列, 连接已经关闭时返回false
+func (c *busConn) push(frame []byte) bool {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	if len(c.queue) >= maxBusQueue {
+		c.mu.Unlock()
+		_ = c.conn.Close()
+		return false
+	}
+	c.queue = append(c.queue, frame)
+	c.mu.Unlock()
+	c.signal()
+	return true
+}
+
+// close 关闭队列, 丢弃尚未写入的消息
+func (c *busConn) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.queue = nil
+	c.mu.Unlock()
+	c.signal()
+}
+
+func (c *busConn) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run 依次写入队列中的消息, 队列关闭或写入失败后退出
+func (c *busConn) run() {
+	for {
+		c.mu.Lock()
+		queue, closed := c.queue, c.closed
+		c.queue = nil
+		c.mu.Unlock()
+		if closed {
+			return
+		}
+
+		for _, frame := range queue {
+			_ = c.conn.SetWriteDeadline(time.Now().Add(busWriteTimeout))
+			if err := writeFrame(c.conn, frame); err != nil {
+				_ = c.conn.Close()
+				c.close()
+				return
+			}
+		}
+		if len(queue) == 0 {
+			<-c.notify
+		}
+	}
+}
+
+func writeFrame(w io.Writer, frame []byte) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(frame)))
+	_, err := w.Write(append(buf[:n:n], frame...))
+	return err
+}
+
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxBusFrame {
+		return nil, ErrBusCorrupt
+	}
+	frame := make([]byte, size)
+	_, err = io.ReadFull(r, frame)
+	return frame, err
+}
@@ -0,0 +1,70 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package bcache
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestUnixBus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.sock")
+	hub, err := NewUnixBus(path)
+	assert.NoError(t, err)
+	b1, err := NewUnixBus(path)
+	assert.NoError(t, err)
+	defer b1.Close()
+	b2, err := NewUnixBus(path)
+	assert.NoError(t, err)
+	defer b2.Close()
+
+	c1 := New[string, int](bcomparator.StringComparator(), SetBus[string, int](b1), SetCapture[string, int](nil))
+	c2 := New[string, int](bcomparator.StringComparator(), SetBus[string, int](b2), SetCapture[string, int](nil))
+	defer c1.Close()
+	defer c2.Close()
+	testBusCache(t, c1, c2)
+
+	// hub 退出后其余实例重新竞争成为hub
+	assert.NoError(t, hub.Close())
+	_, _ = c1.GetOrLoad(context.Background(), "e", func(ctx context.Context, k string) (int, time.Duration, error) {
+		return 5, NoExpire, nil
+	})
+	assert.Eventually(t, func() bool {
+		c2.Set("e", 5, NoExpire)
+		<-time.After(10 * time.Millisecond)
+		_, ok := c1.Get("e")
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestUnixBusSlowPeer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.sock")
+	hub, err := NewUnixBus(path)
+	assert.NoError(t, err)
+	defer hub.Close()
+
+	// 只连接不读取的对端
+	conn, err := net.Dial("unix", path)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.Eventually(t, func() bool {
+		hub.Lock()
+		defer hub.Unlock()
+		return len(hub.conns) == 1
+	}, time.Second, time.Millisecond)
+
+	// 对端的socket缓冲区写满后发布仍然不会阻塞
+	key := make([]byte, 1024)
+	start := time.Now()
+	for i := 0; i < 2*maxBusQueue; i++ {
+		assert.NoError(t, hub.Publish(Invalidation{Source: "a", Key: key}))
+	}
+	assert.Less(t, time.Since(start), busWriteTimeout)
+}
@@ -0,0 +1,159 @@
+package bcache
+
+import (
+	"container/list"
+	"math/bits"
+	"time"
+)
+
+const (
+	// wheelBits 每层的槽位数为 1<<wheelBits
+	wheelBits = 6
+	wheelSize = 1 << wheelBits
+	wheelMask = wheelSize - 1
+	// wheelLevels 层数, 第i层每个槽位的跨度为 tick*64^i
+	wheelLevels = 6
+
+	// defaultWheelShift 默认的 tick 约为1ms
+	defaultWheelShift = 20
+	// maxWheelShift 保证最高层的跨度不超过 int64, tick 最大约为4s
+	maxWheelShift = 62 - (wheelLevels-1)*wheelBits
+)
+
+type wheelNode[K comparable] struct {
+	k      K
+	expire int64
+	// owner 所在的槽位
+	owner *list.List
+}
+
+// timingWheel 分层时间轮过期索引, 写入与删除的复杂度为 O(1)
+// 推进时只处理跨过的槽位: 到期的key移入 due, 未到期的key按照剩余时间重新放入更低层的槽位
+type timingWheel[K comparable] struct {
+	// shift 第0层每个槽位的跨度为 1<<shift 纳秒
+	shift uint
+	// nanos 已经推进到的时间
+	nanos   int64
+	buckets [wheelLevels][wheelSize]list.List
+	// due 已经到期等待移除的key
+	due   list.List
+	items map[K]*list.Element
+}
+
+// newTimingWheel 初始化时间轮, tick 向上取整为2的幂纳秒且不超过 1<<maxWheelShift, now 为当前时间
+func newTimingWheel[K comparable](tick time.Duration, now int64) *timingWheel[K] {
+	shift := uint(defaultWheelShift)
+	if tick > 0 {
+		shift = uint(bits.Len64(uint64(tick - 1)))
+	}
+	if shift > maxWheelShift {
+		shift = maxWheelShift
+	}
+	return &timingWheel[K]{
+		shift: shift,
+		nanos: now,
+		items: make(map[K]*list.Element),
+	}
+}
+
+// levelShift 第i层每个槽位的跨度
+func (w *timingWheel[K]) levelShift(i int) uint {
+	return w.shift + uint(i)*wheelBits
+}
+
+func (w *timingWheel[K]) add(k K, expire int64) {
+	if e, ok := w.items[k]; ok {
+		node := e.Value.(*wheelNode[K])
+		node.owner.Remove(e)
+		node.expire = expire
+		w.place(node)
+		return
+	}
+	w.place(&wheelNode[K]{k: k, expire: expire})
+}
+
+// place 按照过期时间放入对应的槽位
+func (w *timingWheel[K]) place(node *wheelNode[K]) {
+	node.owner = w.bucket(node.expire)
+	w.items[node.k] = node.owner.PushBack(node)
+}
+
+// bucket 返回过期时间对应的槽位, 剩余时间不超过第i层的总跨度时放入第i层
+func (w *timingWheel[K]) bucket(expire int64) *list.List {
+	d := expire - w.nanos
+	if d <= 0 {
+		return &w.due
+	}
+	for i := 0; i < wheelLevels-1; i++ {
+		if uint64(d) < 1<<w.levelShift(i+1) {
+			return &w.buckets[i][(expire>>w.levelShift(i))&wheelMask]
+		}
+	}
+	// 超过最高层的总跨度, 推进到该槽位时重新放置
+	return &w.buckets[wheelLevels-1][(expire>>w.levelShift(wheelLevels-1))&wheelMask]
+}
+
+func (w *timingWheel[K]) remove(k K) {
+	if e, ok := w.items[k]; ok {
+		e.Value.(*wheelNode[K]).owner.Remove(e)
+		delete(w.items, k)
+	}
+}
+
+func (w *timingWheel[K]) expire(now int64, limit int) []K {
+	w.advance(now)
+	n := w.due.Len()
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	ret := make([]K, 0, n)
+	for i := 0; i < n; i++ {
+		node := w.due.Remove(w.due.Front()).(*wheelNode[K])
+		delete(w.items, node.k)
+		ret = append(ret, node.k)
+	}
+	return ret
+}
+
+// advance 推进到now, 依次处理每层从上次推进到now跨过的槽位
+// 第0层总是处理now所在的槽位, 保证当前 tick 内已经到期的key也能及时移除
+func (w *timingWheel[K]) advance(now int64) {
+	if now < w.nanos {
+		return
+	}
+	prev := w.nanos
+	w.nanos = now
+	for i := 0; i < wheelLevels; i++ {
+		shift := w.levelShift(i)
+		prevTicks, nowTicks := prev>>shift, now>>shift
+		delta := nowTicks - prevTicks
+		if delta <= 0 && i > 0 {
+			break
+		}
+		steps := delta + 1
+		if steps > wheelSize {
+			steps = wheelSize
+		}
+		for j := int64(0); j < steps; j++ {
+			w.cascade(&w.buckets[i][(prevTicks+j)&wheelMask])
+		}
+	}
+}
+
+// cascade 重新放置槽位中的key, 到期的key移入 due
+func (w *timingWheel[K]) cascade(b *list.List) {
+	for n := b.Len(); n > 0; n-- {
+		node := b.Remove(b.Front()).(*wheelNode[K])
+		w.place(node)
+	}
+}
+
+func (w *timingWheel[K]) clear() {
+	for i := range w.buckets {
+		for j := range w.buckets[i] {
+			w.buckets[i][j].Init()
+		}
+	}
+	w.due.Init()
+	w.items = make(map[K]*list.Element)
+}
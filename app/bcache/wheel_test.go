@@ -0,0 +1,105 @@
+package bcache
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/go-baseutils/base/bcomparator"
+)
+
+func TestTimingWheel(t *testing.T) {
+	w := newTimingWheel[int](time.Millisecond, 0)
+	ms := int64(time.Millisecond)
+	w.add(1, 5*ms)
+	w.add(2, 500*ms)
+	w.add(3, int64(time.Hour))
+	w.add(4, 1)
+	w.add(5, 10*ms)
+	w.remove(5)
+
+	assert.Equal(t, []int{4}, w.expire(ms, 0))
+	assert.Empty(t, w.expire(4*ms, 0))
+	assert.Equal(t, []int{1}, w.expire(5*ms, 0))
+
+	// 更新过期时间
+	w.add(2, 20*ms)
+	assert.Equal(t, []int{2}, w.expire(20*ms, 0))
+	assert.Empty(t, w.expire(int64(time.Hour)-1, 0))
+	assert.Equal(t, []int{3}, w.expire(int64(time.Hour), 0))
+
+	for i := 0; i < 10; i++ {
+		w.add(i, int64(time.Hour)+ms)
+	}
+	assert.Len(t, w.expire(int64(time.Hour)+ms, 3), 3)
+	assert.Len(t, w.expire(int64(time.Hour)+ms, 0), 7)
+
+	w.add(1, int64(2*time.Hour))
+	w.clear()
+	assert.Empty(t, w.items)
+	assert.Empty(t, w.expire(int64(3*time.Hour), 0))
+}
+
+// TestTimingWheelModel 随机写入以及推进, 与逐个比较过期时间的结果一致
+func TestTimingWheelModel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	now := int64(time.Now().UnixNano())
+	w := newTimingWheel[int](time.Millisecond, now)
+	model := make(map[int]int64)
+	spans := []int64{int64(time.Millisecond), int64(time.Second), int64(time.Minute), int64(24 * time.Hour), int64(30 * 24 * time.Hour)}
+	for round := 0; round < 2000; round++ {
+		for i := 0; i < 5; i++ {
+			k := r.Intn(500)
+			if r.Intn(5) == 0 {
+				w.remove(k)
+				delete(model, k)
+				continue
+			}
+			expire := now + r.Int63n(spans[r.Intn(len(spans))])
+			w.add(k, expire)
+			model[k] = expire
+		}
+		now += r.Int63n(spans[r.Intn(len(spans)-1)])
+		got := w.expire(now, 0)
+		want := make([]int, 0)
+		for k, expire := range model {
+			if expire <= now {
+				want = append(want, k)
+				delete(model, k)
+			}
+		}
+		sort.Ints(got)
+		sort.Ints(want)
+		assert.Equal(t, want, got, "round %d", round)
+	}
+	assert.Len(t, w.items, len(model))
+}
+
+func TestZsetIndexLimit(t *testing.T) {
+	z := newZsetIndex[int](bcomparator.IntComparator())
+	for i := 1; i <= 5; i++ {
+		z.add(i, int64(i))
+	}
+	assert.Equal(t, []int{1, 2}, z.expire(4, 2))
+	assert.Equal(t, []int{3, 4}, z.expire(4, 0))
+	z.clear()
+	assert.Empty(t, z.expire(10, 0))
+}
+
+func BenchmarkExpiryIndexAdd(b *testing.B) {
+	now := time.Now().UnixNano()
+	indexes := map[string]expiryIndex[int]{
+		"zset":  newZsetIndex[int](bcomparator.IntComparator()),
+		"wheel": newTimingWheel[int](time.Millisecond, now),
+	}
+	for name, index := range indexes {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				index.add(i&0xfffff, now+int64(i&0xffff)*int64(time.Millisecond))
+			}
+		})
+	}
+}
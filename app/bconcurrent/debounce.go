@@ -0,0 +1,142 @@
+package bconcurrent
+
+import (
+	"context"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/sys/nanotime"
+)
+
+// Debounce 防抖, 流在quiet时间内没有新的数据时输出最后一个数据, 流关闭时输出尚未输出的数据
+// 与 Batch 相同, quiet 小于等于0时不按时间输出, 只在流关闭时输出最后一个数据
+func Debounce[T any](ctx context.Context, valueStream <-chan T, quiet time.Duration) <-chan T {
+	outStream := make(chan T)
+	go func() {
+		defer close(outStream)
+		var (
+			last    T
+			pending bool
+			timer   = time.NewTimer(quiet)
+		)
+		defer timer.Stop()
+		// 初始时没有数据, 停止定时器
+		if !timer.Stop() {
+			<-timer.C
+		}
+		emit := func() bool {
+			pending = false
+			select {
+			case <-ctx.Done():
+				return false
+			case outStream <- last:
+				return true
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if pending && !emit() {
+					return
+				}
+			case v, ok := <-valueStream:
+				if !ok {
+					if pending {
+						emit()
+					}
+					return
+				}
+				last, pending = v, true
+				if quiet <= 0 {
+					continue
+				}
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(quiet)
+			}
+		}
+	}()
+	return outStream
+}
+
+// ThrottleFirst 输出数据后的interval时间内忽略新的数据, interval 小于等于0时不忽略
+func ThrottleFirst[T any](ctx context.Context, valueStream <-chan T, interval time.Duration) <-chan T {
+	outStream := make(chan T)
+	go func() {
+		defer close(outStream)
+		var next int64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-valueStream:
+				if !ok {
+					return
+				}
+				now := nanotime.RuntimeNanotime()
+				if next != 0 && now < next {
+					continue
+				}
+				next = now + int64(interval)
+				select {
+				case <-ctx.Done():
+					return
+				case outStream <- v:
+				}
+			}
+		}
+	}()
+	return outStream
+}
+
+// ThrottleLast 每隔interval输出一次这段时间内的最后一个数据, 没有数据时不输出, 流关闭时输出尚未输出的数据
+// 与 Batch 相同, interval 小于等于0时不按时间输出, 只在流关闭时输出最后一个数据
+func ThrottleLast[T any](ctx context.Context, valueStream <-chan T, interval time.Duration) <-chan T {
+	outStream := make(chan T)
+	go func() {
+		defer close(outStream)
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		var (
+			last    T
+			pending bool
+		)
+		emit := func() bool {
+			pending = false
+			select {
+			case <-ctx.Done():
+				return false
+			case outStream <- last:
+				return true
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+				if pending && !emit() {
+					return
+				}
+			case v, ok := <-valueStream:
+				if !ok {
+					if pending {
+						emit()
+					}
+					return
+				}
+				last, pending = v, true
+			}
+		}
+	}()
+	return outStream
+}
@@ -0,0 +1,81 @@
+package bconcurrent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebounce(t *testing.T) {
+	in := make(chan int)
+	out := Debounce(context.Background(), in, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	in <- 3
+	assert.Equal(t, 3, <-out)
+
+	// 持续输入时不输出
+	go func() {
+		for i := 4; i < 10; i++ {
+			in <- i
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	start := time.Now()
+	assert.Equal(t, 9, <-out)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	in <- 10
+	close(in)
+	assert.Equal(t, []int{10}, collect(out))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in = make(chan int)
+	out = Debounce(ctx, in, time.Hour)
+	in <- 1
+	cancel()
+	assert.Empty(t, collect(out))
+
+	// quiet 小于等于0时只在关闭时输出
+	out = Debounce(context.Background(), Stream(context.Background(), 1, 2, 3), 0)
+	assert.Equal(t, []int{3}, collect(out))
+}
+
+func TestThrottleFirst(t *testing.T) {
+	in := make(chan int)
+	out := ThrottleFirst(context.Background(), in, 30*time.Millisecond)
+	go func() {
+		defer close(in)
+		for i := 0; i < 3; i++ {
+			in <- i
+		}
+		time.Sleep(50 * time.Millisecond)
+		in <- 3
+		in <- 4
+	}()
+	assert.Equal(t, []int{0, 3}, collect(out))
+}
+
+func TestThrottleLast(t *testing.T) {
+	in := make(chan int)
+	out := ThrottleLast(context.Background(), in, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	// 两次输入可能跨越一次输出
+	v := <-out
+	if v == 1 {
+		v = <-out
+	}
+	assert.Equal(t, 2, v)
+	in <- 3
+	assert.Equal(t, 3, <-out)
+	in <- 4
+	close(in)
+	assert.Equal(t, []int{4}, collect(out))
+
+	// interval 小于等于0时只在关闭时输出
+	out = ThrottleLast(context.Background(), Stream(context.Background(), 1, 2, 3), 0)
+	assert.Equal(t, []int{3}, collect(out))
+}
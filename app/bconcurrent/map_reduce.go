@@ -1,5 +1,15 @@
 package bconcurrent
 
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/songzhibin97/go-baseutils/base/options"
+	"github.com/songzhibin97/go-baseutils/internal/keyhash"
+)
+
 func MapChan[T any](in <-chan T, fn func(T) T) <-chan T {
 	out := make(chan T, 1)
 	if in == nil {
@@ -26,3 +36,180 @@ func ReduceChan[T any](in <-chan T, fn func(r, v T) T) T {
 	}
 	return out
 }
+
+type MapReduceConfig[K comparable] struct {
+	// mappers 执行mapper的协程数量
+	mappers int
+	// reducers 执行reducer的协程数量, 也是shuffle的分区数量
+	reducers int
+	// buffer 每个分区channel的缓冲大小
+	buffer int
+	// hasher 按照key计算分区
+	hasher func(k K) uint64
+}
+
+// SetMapReduceMappers 设置执行mapper的协程数量, 默认(不设置)为 runtime.NumCPU()
+func SetMapReduceMappers[K comparable](n int) options.Option[*MapReduceConfig[K]] {
+	return func(c *MapReduceConfig[K]) {
+		c.mappers = n
+	}
+}
+
+// SetMapReduceReducers 设置执行reducer的协程数量, 默认(不设置)为 runtime.NumCPU()
+func SetMapReduceReducers[K comparable](n int) options.Option[*MapReduceConfig[K]] {
+	return func(c *MapReduceConfig[K]) {
+		c.reducers = n
+	}
+}
+
+// SetMapReduceBuffer 设置每个分区channel的缓冲大小, 默认(不设置)为0
+func SetMapReduceBuffer[K comparable](n int) options.Option[*MapReduceConfig[K]] {
+	return func(c *MapReduceConfig[K]) {
+		c.buffer = n
+	}
+}
+
+// SetMapReduceHasher 设置计算分区的hash函数, 默认(不设置)字符串与数值类型直接计算, 其他类型按照 fmt 格式化后的字符串计算
+func SetMapReduceHasher[K comparable](hasher func(k K) uint64) options.Option[*MapReduceConfig[K]] {
+	return func(c *MapReduceConfig[K]) {
+		c.hasher = hasher
+	}
+}
+
+// mapReducePair mapper输出的kv
+type mapReducePair[K comparable, V any] struct {
+	k K
+	v V
+}
+
+// MapReduce 并发执行mapper, 将输出的kv按照key的hash分区给reducer, 每个key调用一次reducer得到结果
+// mapper 通过emit输出kv, emit 在任务取消后返回 ctx.Err()
+// 任意mapper或reducer返回错误(panic 会被转换为 *PanicError)时取消任务并返回第一个错误, ctx 结束时返回 ctx.Err()
+func MapReduce[T any, K comparable, V, R any](
+	ctx context.Context,
+	in <-chan T,
+	mapper func(ctx context.Context, v T, emit func(k K, v V) error) error,
+	reducer func(ctx context.Context, k K, vs []V) (R, error),
+	opts ...options.Option[*MapReduceConfig[K]],
+) (map[K]R, error) {
+	c := &MapReduceConfig[K]{
+		mappers:  runtime.NumCPU(),
+		reducers: runtime.NumCPU(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.mappers < 1 {
+		c.mappers = 1
+	}
+	if c.reducers < 1 {
+		c.reducers = 1
+	}
+	if c.hasher == nil {
+		c.hasher = keyhash.Default[K]()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	partitions := make([]chan mapReducePair[K, V], c.reducers)
+	for i := range partitions {
+		partitions[i] = make(chan mapReducePair[K, V], c.buffer)
+	}
+	emit := func(k K, v V) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case partitions[c.hasher(k)%uint64(c.reducers)] <- mapReducePair[K, V]{k: k, v: v}:
+			return nil
+		}
+	}
+
+	var mappers sync.WaitGroup
+	mappers.Add(c.mappers)
+	for i := 0; i < c.mappers; i++ {
+		go func() {
+			defer mappers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					err := call(func() error { return mapper(ctx, v, emit) })
+					if err != nil {
+						if ctx.Err() == nil || !errors.Is(err, ctx.Err()) {
+							fail(err)
+						}
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		// 所有mapper退出后关闭分区, reducer 开始执行
+		mappers.Wait()
+		for _, p := range partitions {
+			close(p)
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		ret     = make(map[K]R)
+		reduces sync.WaitGroup
+	)
+	reduces.Add(c.reducers)
+	for _, p := range partitions {
+		p := p
+		go func() {
+			defer reduces.Done()
+			groups := make(map[K][]V)
+			for pair := range p {
+				groups[pair.k] = append(groups[pair.k], pair.v)
+			}
+			for k, vs := range groups {
+				if ctx.Err() != nil {
+					return
+				}
+				var r R
+				err := call(func() (err error) {
+					r, err = reducer(ctx, k, vs)
+					return err
+				})
+				if err != nil {
+					if ctx.Err() == nil || !errors.Is(err, ctx.Err()) {
+						fail(err)
+					}
+					return
+				}
+				mu.Lock()
+				ret[k] = r
+				mu.Unlock()
+			}
+		}()
+	}
+	reduces.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,123 @@
+package bconcurrent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sliceChan[T any](values ...T) <-chan T {
+	in := make(chan T, len(values))
+	for _, v := range values {
+		in <- v
+	}
+	close(in)
+	return in
+}
+
+func wordCount(ctx context.Context, line string, emit func(k string, v int) error) error {
+	for _, w := range strings.Fields(line) {
+		if err := emit(w, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sum(ctx context.Context, k string, vs []int) (int, error) {
+	n := 0
+	for _, v := range vs {
+		n += v
+	}
+	return n, nil
+}
+
+func TestMapReduce(t *testing.T) {
+	lines := sliceChan("a b c", "b c", "c", "d a")
+	ret, err := MapReduce(context.Background(), lines, wordCount, sum,
+		SetMapReduceMappers[string](3), SetMapReduceReducers[string](2), SetMapReduceBuffer[string](4))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 2, "b": 2, "c": 3, "d": 1}, ret)
+
+	// 所有key分到同一个分区
+	ret, err = MapReduce(context.Background(), sliceChan("x y x"), wordCount, sum,
+		SetMapReduceReducers[string](4), SetMapReduceHasher(func(k string) uint64 { return 0 }))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"x": 2, "y": 1}, ret)
+
+	ret, err = MapReduce(context.Background(), sliceChan[string](), wordCount, sum)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+}
+
+func TestMapReduceGroup(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	users := sliceChan(user{"a", 10}, user{"b", 20}, user{"c", 10})
+	ret, err := MapReduce(context.Background(), users,
+		func(ctx context.Context, u user, emit func(k int, v string) error) error {
+			return emit(u.Age, u.Name)
+		},
+		func(ctx context.Context, k int, vs []string) (int, error) {
+			return len(vs), nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]int{10: 2, 20: 1}, ret)
+}
+
+func TestMapReduceError(t *testing.T) {
+	errMap := errors.New("map failed")
+	// 无限的输入, 只能依靠取消退出
+	in := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case in <- i:
+			case <-time.After(100 * time.Millisecond):
+				return
+			}
+		}
+	}()
+	_, err := MapReduce(context.Background(), in,
+		func(ctx context.Context, v int, emit func(k int, v int) error) error {
+			if v == 100 {
+				return errMap
+			}
+			return emit(v%10, v)
+		},
+		func(ctx context.Context, k int, vs []int) (int, error) {
+			return len(vs), nil
+		})
+	assert.ErrorIs(t, err, errMap)
+
+	errReduce := errors.New("reduce failed")
+	_, err = MapReduce(context.Background(), sliceChan("a b"), wordCount,
+		func(ctx context.Context, k string, vs []int) (int, error) {
+			if k == "b" {
+				return 0, errReduce
+			}
+			return len(vs), nil
+		})
+	assert.ErrorIs(t, err, errReduce)
+
+	_, err = MapReduce(context.Background(), sliceChan("a"), wordCount,
+		func(ctx context.Context, k string, vs []int) (int, error) {
+			panic("boom")
+		})
+	var pe *PanicError
+	assert.True(t, errors.As(err, &pe))
+}
+
+func TestMapReduceContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	in := make(chan string)
+	_, err := MapReduce(ctx, in, wordCount, sum)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,126 @@
+package bconcurrent
+
+import (
+	"context"
+	"sync"
+)
+
+// reorderFactor 重排窗口相对于worker数量的倍数
+const reorderFactor = 2
+
+// parallelJob 待处理的数据以及存放结果的channel
+type parallelJob[In, Out any] struct {
+	v   In
+	ret chan Out
+}
+
+// ParallelMap 使用workers个协程并发执行fn, 按照输入顺序输出结果
+// 已读取但尚未输出的数据约为 workers 的 reorderFactor 倍, 处理较慢的数据会阻塞后续数据的读取
+// ctx 结束后停止读取in并关闭输出
+func ParallelMap[In, Out any](ctx context.Context, in <-chan In, workers int, fn func(ctx context.Context, v In) Out) <-chan Out {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan Out, workers)
+	if in == nil {
+		close(out)
+		return out
+	}
+	// pending 按照输入顺序排列的结果, 容量即为重排窗口
+	pending := make(chan chan Out, reorderFactor*workers)
+	jobs := make(chan parallelJob[In, Out])
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				ret := make(chan Out, 1)
+				select {
+				case <-ctx.Done():
+					return
+				case pending <- ret:
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case jobs <- parallelJob[In, Out]{v: v, ret: ret}:
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				// ret 有缓冲, 不会阻塞
+				job.ret <- fn(ctx, job.v)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		for ret := range pending {
+			var v Out
+			select {
+			case <-ctx.Done():
+				return
+			case v = <-ret:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+	return out
+}
+
+// ParallelMapUnordered 使用workers个协程并发执行fn, 结果按照完成顺序输出
+// ctx 结束后停止读取in并关闭输出
+func ParallelMapUnordered[In, Out any](ctx context.Context, in <-chan In, workers int, fn func(ctx context.Context, v In) Out) <-chan Out {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan Out, workers)
+	if in == nil {
+		close(out)
+		return out
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					ret := fn(ctx, v)
+					select {
+					case <-ctx.Done():
+						return
+					case out <- ret:
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,118 @@
+package bconcurrent
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func generate(n int) <-chan int {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < n; i++ {
+			in <- i
+		}
+	}()
+	return in
+}
+
+func TestParallelMap(t *testing.T) {
+	var running, peak int32
+	out := ParallelMap(context.Background(), generate(100), 4, func(ctx context.Context, v int) int {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		atomic.AddInt32(&running, -1)
+		return v * v
+	})
+	var ret []int
+	for v := range out {
+		ret = append(ret, v)
+	}
+	assert.Len(t, ret, 100)
+	for i, v := range ret {
+		assert.Equal(t, i*i, v)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(4))
+	assert.Greater(t, atomic.LoadInt32(&peak), int32(1))
+
+	out = ParallelMap[int, int](context.Background(), nil, 4, nil)
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestParallelMapWindow(t *testing.T) {
+	// 第一个数据阻塞时, 最多读取 reorderFactor*workers 个数据
+	var read int32
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < 100; i++ {
+			in <- i
+			atomic.AddInt32(&read, 1)
+		}
+	}()
+	release := make(chan struct{})
+	out := ParallelMap(context.Background(), in, 2, func(ctx context.Context, v int) int {
+		if v == 0 {
+			<-release
+		}
+		return v
+	})
+	time.Sleep(20 * time.Millisecond)
+	assert.LessOrEqual(t, atomic.LoadInt32(&read), int32(reorderFactor*2+2))
+
+	close(release)
+	var ret []int
+	for v := range out {
+		ret = append(ret, v)
+	}
+	assert.Len(t, ret, 100)
+	assert.True(t, sort.IntsAreSorted(ret))
+}
+
+func TestParallelMapUnordered(t *testing.T) {
+	out := ParallelMapUnordered(context.Background(), generate(100), 4, func(ctx context.Context, v int) int {
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		return v * 2
+	})
+	var ret []int
+	for v := range out {
+		ret = append(ret, v)
+	}
+	sort.Ints(ret)
+	assert.Len(t, ret, 100)
+	for i, v := range ret {
+		assert.Equal(t, i*2, v)
+	}
+}
+
+func TestParallelMapCancel(t *testing.T) {
+	for name, pm := range map[string]func(context.Context, <-chan int, int, func(context.Context, int) int) <-chan int{
+		"ordered":   ParallelMap[int, int],
+		"unordered": ParallelMapUnordered[int, int],
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			in := make(chan int)
+			out := pm(ctx, in, 2, func(ctx context.Context, v int) int { return v })
+			in <- 1
+			assert.Equal(t, 1, <-out)
+			cancel()
+			// 取消后输出会被关闭, 不再需要关闭in
+			for range out {
+			}
+		})
+	}
+}
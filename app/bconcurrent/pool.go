@@ -0,0 +1,367 @@
+package bconcurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/base/options"
+)
+
+var (
+	ErrPoolClosed = errors.New("bconcurrent: pool closed")
+	ErrPoolFull   = errors.New("bconcurrent: pool queue full")
+)
+
+// PanicError 任务panic时返回的错误
+type PanicError struct {
+	// Value recover 得到的值
+	Value interface{}
+	// Stack panic 时的调用栈
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("bconcurrent: task panic: %v", e.Value)
+}
+
+// RejectPolicy 队列已满时的处理策略
+type RejectPolicy int
+
+const (
+	// PolicyBlock 阻塞直到队列有空位或ctx结束
+	PolicyBlock RejectPolicy = iota
+	// PolicyReject 直接返回 ErrPoolFull
+	PolicyReject
+	// PolicyCallerRuns 在调用 Submit 的协程中直接执行
+	PolicyCallerRuns
+)
+
+type PoolConfig struct {
+	// minWorkers 常驻的worker数量
+	minWorkers int
+	// maxWorkers 最大的worker数量, 大于 minWorkers 时按需扩容
+	maxWorkers int
+	// queueSize 等待队列的大小
+	queueSize int
+	// policy 队列已满时的处理策略
+	policy RejectPolicy
+	// idleTimeout 扩容的worker空闲超过该时间后退出
+	idleTimeout time.Duration
+	// onError 任务返回错误或panic时的回调
+	onError func(err error)
+}
+
+// SetPoolWorkers 设置worker数量, min 与 max 相等时为固定数量, 否则在所有worker繁忙时扩容到max
+// 默认(不设置)为 runtime.NumCPU() 个固定数量的worker
+func SetPoolWorkers(min, max int) options.Option[*PoolConfig] {
+	return func(c *PoolConfig) {
+		c.minWorkers = min
+		c.maxWorkers = max
+	}
+}
+
+// SetPoolQueueSize 设置等待队列的大小, 默认(不设置)与 maxWorkers 相同
+func SetPoolQueueSize(size int) options.Option[*PoolConfig] {
+	return func(c *PoolConfig) {
+		c.queueSize = size
+	}
+}
+
+// SetPoolRejectPolicy 设置队列已满时的处理策略, 默认(不设置)为 PolicyBlock
+func SetPoolRejectPolicy(policy RejectPolicy) options.Option[*PoolConfig] {
+	return func(c *PoolConfig) {
+		c.policy = policy
+	}
+}
+
+// SetPoolIdleTimeout 设置扩容的worker空闲多久后退出, 默认(不设置)为1s
+func SetPoolIdleTimeout(d time.Duration) options.Option[*PoolConfig] {
+	return func(c *PoolConfig) {
+		c.idleTimeout = d
+	}
+}
+
+// SetPoolErrorHandler 设置任务返回错误或panic时的回调, panic 会被转换为 *PanicError
+func SetPoolErrorHandler(fn func(err error)) options.Option[*PoolConfig] {
+	return func(c *PoolConfig) {
+		c.onError = fn
+	}
+}
+
+// poolTask 提交的任务
+type poolTask struct {
+	ctx  context.Context
+	fn   func(ctx context.Context) error
+	done chan error
+}
+
+// Pool 有界的协程池
+type Pool struct {
+	config *PoolConfig
+
+	queue chan *poolTask
+	// quit 关闭后阻塞的 Submit 立即返回
+	quit chan struct{}
+
+	mu     sync.Mutex // Protection closed&cancelled&running
+	closed bool
+	// cancelled 为true时取消运行中的任务并丢弃队列中的任务
+	cancelled bool
+	running   map[uint64]context.CancelFunc
+	nextID    uint64
+
+	// submitting 正在向队列发送的 Submit
+	submitting sync.WaitGroup
+	wg         sync.WaitGroup
+
+	workers int32
+	busy    int32
+}
+
+// NewPool 初始化协程池并启动常驻的worker
+func NewPool(opts ...options.Option[*PoolConfig]) *Pool {
+	c := &PoolConfig{
+		idleTimeout: time.Second,
+	}
+	for _, option := range opts {
+		option(c)
+	}
+	if c.minWorkers <= 0 && c.maxWorkers <= 0 {
+		c.minWorkers = runtime.NumCPU()
+	}
+	if c.minWorkers < 0 {
+		c.minWorkers = 0
+	}
+	if c.maxWorkers < c.minWorkers {
+		c.maxWorkers = c.minWorkers
+	}
+	if c.maxWorkers == 0 {
+		c.maxWorkers = 1
+	}
+	if c.queueSize <= 0 {
+		c.queueSize = c.maxWorkers
+	}
+	p := &Pool{
+		config:  c,
+		queue:   make(chan *poolTask, c.queueSize),
+		quit:    make(chan struct{}),
+		running: make(map[uint64]context.CancelFunc),
+	}
+	for i := 0; i < c.minWorkers; i++ {
+		atomic.AddInt32(&p.workers, 1)
+		p.wg.Add(1)
+		go p.worker(true)
+	}
+	return p
+}
+
+// Submit 提交任务, 返回提交的错误, 任务的错误通过 SetPoolErrorHandler 回调
+// 任务的ctx继承自提交时的ctx, Shutdown 超时后会被取消
+func (p *Pool) Submit(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.submit(ctx, &poolTask{ctx: ctx, fn: fn})
+}
+
+// SubmitWait 提交任务并等待执行完成, 返回提交的错误或任务的错误
+func (p *Pool) SubmitWait(ctx context.Context, fn func(ctx context.Context) error) error {
+	t := &poolTask{ctx: ctx, fn: fn, done: make(chan error, 1)}
+	if err := p.submit(ctx, t); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-t.done:
+		return err
+	}
+}
+
+func (p *Pool) submit(ctx context.Context, t *poolTask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.submitting.Add(1)
+	p.mu.Unlock()
+	defer p.submitting.Done()
+
+	p.grow()
+	select {
+	case p.queue <- t:
+		// 扩容的worker可能在发送前因空闲退出
+		p.grow()
+		return nil
+	default:
+	}
+	switch p.config.policy {
+	case PolicyReject:
+		return ErrPoolFull
+	case PolicyCallerRuns:
+		p.run(t)
+		return nil
+	}
+	select {
+	case p.queue <- t:
+		p.grow()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.quit:
+		return ErrPoolClosed
+	}
+}
+
+// grow 所有worker繁忙且未达到 maxWorkers 时扩容
+func (p *Pool) grow() {
+	for {
+		w := atomic.LoadInt32(&p.workers)
+		if int(w) >= p.config.maxWorkers || atomic.LoadInt32(&p.busy) < w {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&p.workers, w, w+1) {
+			p.wg.Add(1)
+			go p.worker(false)
+			return
+		}
+	}
+}
+
+// worker 执行队列中的任务, 扩容的worker空闲超过 idleTimeout 后退出
+func (p *Pool) worker(core bool) {
+	defer p.wg.Done()
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if !core {
+		timer = time.NewTimer(p.config.idleTimeout)
+		defer timer.Stop()
+	}
+	for {
+		if timer != nil {
+			idle = timer.C
+		}
+		select {
+		case t, ok := <-p.queue:
+			if !ok {
+				atomic.AddInt32(&p.workers, -1)
+				return
+			}
+			p.run(t)
+			if timer != nil {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(p.config.idleTimeout)
+			}
+		case <-idle:
+			if int(atomic.AddInt32(&p.workers, -1)) >= p.config.minWorkers && len(p.queue) == 0 {
+				return
+			}
+			// 常驻的worker数量不足或队列中仍有任务时继续运行
+			atomic.AddInt32(&p.workers, 1)
+			timer.Reset(p.config.idleTimeout)
+		}
+	}
+}
+
+// run 执行任务并回调错误
+func (p *Pool) run(t *poolTask) {
+	atomic.AddInt32(&p.busy, 1)
+	defer atomic.AddInt32(&p.busy, -1)
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	p.mu.Lock()
+	if p.cancelled {
+		cancel()
+	}
+	id := p.nextID
+	p.nextID++
+	p.running[id] = cancel
+	p.mu.Unlock()
+
+	var err error
+	if ctx.Err() != nil {
+		// 已取消的任务不再执行
+		err = ctx.Err()
+	} else {
+		err = call(func() error { return t.fn(ctx) })
+	}
+
+	p.mu.Lock()
+	delete(p.running, id)
+	p.mu.Unlock()
+
+	if t.done != nil {
+		t.done <- err
+	}
+	if err != nil && p.config.onError != nil {
+		p.config.onError(err)
+	}
+}
+
+// call 执行fn, 将panic转换为 *PanicError, 协程池以及流水线等共用
+func call(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Workers 返回当前的worker数量
+func (p *Pool) Workers() int {
+	return int(atomic.LoadInt32(&p.workers))
+}
+
+// Queued 返回队列中等待执行的任务数量
+func (p *Pool) Queued() int {
+	return len(p.queue)
+}
+
+// Shutdown 停止接收新任务并等待队列中的任务执行完成
+// ctx 结束时取消运行中任务的ctx, 丢弃队列中尚未执行的任务, 等待worker退出后返回 ctx.Err()
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.quit)
+		p.mu.Unlock()
+		p.submitting.Wait()
+		close(p.queue)
+	} else {
+		p.mu.Unlock()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	p.cancelled = true
+	for _, cancel := range p.running {
+		cancel()
+	}
+	p.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
@@ -0,0 +1,147 @@
+package bconcurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	var errs []error
+	var mu sync.Mutex
+	p := NewPool(SetPoolWorkers(2, 2), SetPoolErrorHandler(func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+
+	var sum int64
+	for i := 1; i <= 100; i++ {
+		i := i
+		assert.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+			atomic.AddInt64(&sum, int64(i))
+			return nil
+		}))
+	}
+	errTask := errors.New("task failed")
+	assert.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+		return errTask
+	}))
+	assert.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	err := p.SubmitWait(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	})
+	var pe *PanicError
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, "boom", pe.Value)
+
+	assert.NoError(t, p.Shutdown(context.Background()))
+	assert.Equal(t, int64(5050), sum)
+	assert.Len(t, errs, 3)
+	failed := 0
+	for _, err := range errs {
+		if errors.Is(err, errTask) {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, 0, p.Workers())
+
+	assert.ErrorIs(t, p.Submit(context.Background(), func(ctx context.Context) error { return nil }), ErrPoolClosed)
+	assert.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestPoolRejectPolicy(t *testing.T) {
+	release := make(chan struct{})
+	block := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+
+	p := NewPool(SetPoolWorkers(1, 1), SetPoolQueueSize(1), SetPoolRejectPolicy(PolicyReject))
+	assert.NoError(t, p.Submit(context.Background(), block))
+	assert.Eventually(t, func() bool { return p.Queued() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, p.Submit(context.Background(), block))
+	assert.ErrorIs(t, p.Submit(context.Background(), block), ErrPoolFull)
+
+	q := NewPool(SetPoolWorkers(1, 1), SetPoolQueueSize(1), SetPoolRejectPolicy(PolicyCallerRuns))
+	assert.NoError(t, q.Submit(context.Background(), block))
+	assert.Eventually(t, func() bool { return q.Queued() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, q.Submit(context.Background(), block))
+	ran := false
+	assert.NoError(t, q.Submit(context.Background(), func(ctx context.Context) error {
+		ran = true
+		return nil
+	}))
+	assert.True(t, ran)
+
+	// 阻塞直到ctx结束
+	b := NewPool(SetPoolWorkers(1, 1), SetPoolQueueSize(1))
+	assert.NoError(t, b.Submit(context.Background(), block))
+	assert.Eventually(t, func() bool { return b.Queued() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, b.Submit(context.Background(), block))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Submit(ctx, block), context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, p.Shutdown(context.Background()))
+	assert.NoError(t, q.Shutdown(context.Background()))
+	assert.NoError(t, b.Shutdown(context.Background()))
+}
+
+func TestPoolElastic(t *testing.T) {
+	p := NewPool(SetPoolWorkers(1, 4), SetPoolQueueSize(1), SetPoolIdleTimeout(10*time.Millisecond))
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+			<-release
+			return nil
+		}))
+	}
+	assert.Eventually(t, func() bool { return p.Workers() == 4 }, time.Second, time.Millisecond)
+	close(release)
+	assert.Eventually(t, func() bool { return p.Workers() == 1 }, time.Second, time.Millisecond)
+
+	// 空闲退出后仍然可以执行任务
+	assert.NoError(t, p.SubmitWait(context.Background(), func(ctx context.Context) error { return nil }))
+	assert.NoError(t, p.Shutdown(context.Background()))
+	assert.Equal(t, 0, p.Workers())
+}
+
+func TestPoolShutdownCancel(t *testing.T) {
+	var cancelled, skipped int32
+	p := NewPool(SetPoolWorkers(1, 1), SetPoolQueueSize(4), SetPoolErrorHandler(func(err error) {
+		if errors.Is(err, context.Canceled) {
+			atomic.AddInt32(&skipped, 1)
+		}
+	}))
+	started := make(chan struct{})
+	assert.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		atomic.AddInt32(&cancelled, 1)
+		return nil
+	}))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+			t.Error("queued task should not run")
+			return nil
+		}))
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cancelled))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&skipped))
+}
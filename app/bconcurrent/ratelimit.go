@@ -0,0 +1,398 @@
+package bconcurrent
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/go-baseutils/sys/nanotime"
+)
+
+var (
+	ErrLimitExceedBurst = errors.New("bconcurrent: n exceeds limiter burst")
+	ErrLimitDeadline    = errors.New("bconcurrent: wait would exceed context deadline")
+	ErrLimitExhausted   = errors.New("bconcurrent: limiter exhausted and never refills")
+)
+
+var (
+	_ Limiter = (*TokenBucket)(nil)
+	_ Limiter = (*SlidingWindowLimiter)(nil)
+)
+
+// infiniteWait 不限制等待时间
+const infiniteWait = int64(math.MaxInt64)
+
+// Limiter 限流器
+type Limiter interface {
+	// Allow 当前是否允许1个事件
+	Allow() bool
+	// AllowN 当前是否允许n个事件
+	AllowN(n int) bool
+	// Wait 阻塞直到允许1个事件或ctx结束
+	Wait(ctx context.Context) error
+	// WaitN 阻塞直到允许n个事件或ctx结束
+	WaitN(ctx context.Context, n int) error
+	// Reserve 预留1个事件, 调用方需要等待 Reservation.Delay 后再执行
+	Reserve() *Reservation
+	// ReserveN 预留n个事件
+	ReserveN(n int) *Reservation
+}
+
+// Reservation 预留的事件
+type Reservation struct {
+	ok bool
+	// err 预留失败的原因
+	err error
+	// at 允许执行的时间(nanotime)
+	at     int64
+	cancel func()
+	once   sync.Once
+}
+
+// OK 是否预留成功, n 超过限流器的容量时预留失败
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay 距离允许执行还需要等待的时间, 预留失败时返回 math.MaxInt64
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return math.MaxInt64
+	}
+	d := r.at - nanotime.RuntimeNanotime()
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// Cancel 取消预留, 归还预留的额度, 已经到达允许执行的时间后取消不会归还
+func (r *Reservation) Cancel() {
+	if !r.ok || r.cancel == nil {
+		return
+	}
+	r.once.Do(r.cancel)
+}
+
+// reserver 限流器的预留实现, maxWait 为允许等待的最长时间(纳秒)
+type reserver interface {
+	reserve(now int64, n int, maxWait int64) *Reservation
+}
+
+// waitN 预留n个事件并等待到允许执行, ctx 结束时取消预留
+func waitN(ctx context.Context, r reserver, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := nanotime.RuntimeNanotime()
+	maxWait := infiniteWait
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = int64(time.Until(deadline))
+	}
+	rv := r.reserve(now, n, maxWait)
+	if !rv.ok {
+		return rv.err
+	}
+	d := rv.Delay()
+	if d == 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		rv.Cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// =====================================================================================================================
+// token bucket
+
+// TokenBucket 令牌桶限流器, 每秒补充rate个令牌, 最多积累burst个令牌
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	// last 上次补充令牌的时间(nanotime)
+	last int64
+	// lastEvent 最后一个预留允许执行的时间(nanotime)
+	lastEvent int64
+}
+
+// NewTokenBucket 初始化令牌桶, 初始时令牌是满的, burst 小于1时修正为1
+// rate 小于等于0时不补充令牌, 只能使用初始的burst个令牌, 用完后预留以及等待返回 ErrLimitExhausted
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   nanotime.RuntimeNanotime(),
+	}
+}
+
+// advance 补充从last到now的令牌, 调用方需要持有锁
+func (b *TokenBucket) advance(now int64) {
+	if now <= b.last {
+		return
+	}
+	if b.rate > 0 {
+		b.tokens += float64(now-b.last) / float64(time.Second) * b.rate
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+	}
+	b.last = now
+}
+
+func (b *TokenBucket) reserve(now int64, n int, maxWait int64) *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > b.burst {
+		return &Reservation{err: ErrLimitExceedBurst}
+	}
+	b.advance(now)
+	tokens := b.tokens - float64(n)
+	var wait int64
+	if tokens < 0 {
+		if b.rate <= 0 {
+			return &Reservation{err: ErrLimitExhausted}
+		}
+		wait = int64(math.Ceil(-tokens / b.rate * float64(time.Second)))
+	}
+	if wait > maxWait {
+		return &Reservation{err: ErrLimitDeadline}
+	}
+	b.tokens = tokens
+	at := now + wait
+	if at > b.lastEvent {
+		b.lastEvent = at
+	}
+	return &Reservation{
+		ok: true,
+		at: at,
+		cancel: func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.cancel(at, n)
+		},
+	}
+}
+
+// cancel 取消在at执行的n个令牌的预留, 调用方需要持有锁
+// 已经到达at时令牌已经被使用, 不归还; 之后的预留已经占用了at之后补充的令牌, 只归还没有被占用的部分
+func (b *TokenBucket) cancel(at int64, n int) {
+	now := nanotime.RuntimeNanotime()
+	if at <= now {
+		return
+	}
+	restore := float64(n)
+	if b.rate > 0 {
+		restore -= float64(b.lastEvent-at) / float64(time.Second) * b.rate
+	}
+	if restore <= 0 {
+		return
+	}
+	b.advance(now)
+	b.tokens += restore
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	if at == b.lastEvent && b.rate > 0 {
+		// 最后一个预留被取消, 回退到它之前的预留
+		if prev := at - int64(float64(n)/b.rate*float64(time.Second)); prev >= now {
+			b.lastEvent = prev
+		}
+	}
+}
+
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+func (b *TokenBucket) AllowN(n int) bool {
+	return b.reserve(nanotime.RuntimeNanotime(), n, 0).ok
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return waitN(ctx, b, 1)
+}
+
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	return waitN(ctx, b, n)
+}
+
+func (b *TokenBucket) Reserve() *Reservation {
+	return b.ReserveN(1)
+}
+
+func (b *TokenBucket) ReserveN(n int) *Reservation {
+	return b.reserve(nanotime.RuntimeNanotime(), n, infiniteWait)
+}
+
+// Tokens 当前可用的令牌数量, 有未到期的预留时为负数
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(nanotime.RuntimeNanotime())
+	return b.tokens
+}
+
+// =====================================================================================================================
+// sliding window
+
+// SlidingWindowLimiter 滑动窗口限流器, 任意长度为window的时间段内最多允许limit个事件
+type SlidingWindowLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window int64
+	// log 尚未滑出窗口的事件的时间(nanotime), 按时间从早到晚排列, 包括预留的未来事件
+	log []int64
+}
+
+// NewSlidingWindowLimiter 初始化滑动窗口限流器, limit 小于1时修正为1, window 小于等于0时不限制
+func NewSlidingWindowLimiter(limit int, window time.Duration) *SlidingWindowLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &SlidingWindowLimiter{
+		limit:  limit,
+		window: int64(window),
+		log:    make([]int64, 0, limit),
+	}
+}
+
+func (l *SlidingWindowLimiter) reserve(now int64, n int, maxWait int64) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n > l.limit {
+		return &Reservation{err: ErrLimitExceedBurst}
+	}
+	at := now
+	// 在at之前的window内最多只能有 limit-n 个事件, 即倒数第 limit-n+1 个事件需要滑出窗口
+	if i := len(l.log) - (l.limit - n + 1); i >= 0 && l.log[i]+l.window > at {
+		at = l.log[i] + l.window
+	}
+	// 保证log有序
+	if len(l.log) != 0 && l.log[len(l.log)-1] > at {
+		at = l.log[len(l.log)-1]
+	}
+	if at-now > maxWait {
+		return &Reservation{err: ErrLimitDeadline}
+	}
+	// 移除已经滑出窗口的事件
+	i := 0
+	for i < len(l.log) && l.log[i]+l.window <= now {
+		i++
+	}
+	l.log = append(l.log[:0], l.log[i:]...)
+	for i := 0; i < n; i++ {
+		l.log = append(l.log, at)
+	}
+	return &Reservation{
+		ok: true,
+		at: at,
+		cancel: func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.remove(at, n)
+		},
+	}
+}
+
+// remove 从log中移除最多n个时间为at的事件, 调用方需要持有锁
+func (l *SlidingWindowLimiter) remove(at int64, n int) {
+	for i := len(l.log) - 1; i >= 0 && n > 0; i-- {
+		if l.log[i] == at {
+			l.log = append(l.log[:i], l.log[i+1:]...)
+			n--
+		}
+	}
+}
+
+func (l *SlidingWindowLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *SlidingWindowLimiter) AllowN(n int) bool {
+	return l.reserve(nanotime.RuntimeNanotime(), n, 0).ok
+}
+
+func (l *SlidingWindowLimiter) Wait(ctx context.Context) error {
+	return waitN(ctx, l, 1)
+}
+
+func (l *SlidingWindowLimiter) WaitN(ctx context.Context, n int) error {
+	return waitN(ctx, l, n)
+}
+
+func (l *SlidingWindowLimiter) Reserve() *Reservation {
+	return l.ReserveN(1)
+}
+
+func (l *SlidingWindowLimiter) ReserveN(n int) *Reservation {
+	return l.reserve(nanotime.RuntimeNanotime(), n, infiniteWait)
+}
+
+// Throttle 按照令牌桶限制流的输出速率, 每秒最多输出rate个数据, 允许突发burst个
+// rate 小于等于0时不限制速率, 等待令牌时只在ctx结束时退出, 不会因为ctx的deadline早于下一个令牌而提前丢弃数据
+func Throttle[T any](ctx context.Context, valueStream <-chan T, rate float64, burst int) <-chan T {
+	if burst < 1 {
+		burst = 1
+	}
+	var limiter *TokenBucket
+	if rate > 0 {
+		limiter = NewTokenBucket(rate, burst)
+	}
+	// wait 等待到允许输出, ctx 结束时取消预留并返回false
+	wait := func() bool {
+		if limiter == nil {
+			return true
+		}
+		r := limiter.Reserve()
+		d := r.Delay()
+		if d == 0 {
+			return true
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			r.Cancel()
+			return false
+		case <-timer.C:
+			return true
+		}
+	}
+	outStream := make(chan T)
+	go func() {
+		defer close(outStream)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-valueStream:
+				if !ok {
+					return
+				}
+				if !wait() {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case outStream <- v:
+				}
+			}
+		}
+	}()
+	return outStream
+}
@@ -0,0 +1,153 @@
+package bconcurrent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 3)
+	assert.True(t, b.Allow())
+	assert.True(t, b.AllowN(2))
+	assert.False(t, b.Allow())
+	assert.False(t, b.AllowN(4))
+
+	time.Sleep(25 * time.Millisecond)
+	assert.True(t, b.AllowN(2))
+
+	r := b.ReserveN(3)
+	assert.True(t, r.OK())
+	assert.Greater(t, r.Delay(), 10*time.Millisecond)
+	assert.LessOrEqual(t, r.Delay(), 30*time.Millisecond)
+	r.Cancel()
+	r.Cancel()
+	assert.Greater(t, b.Tokens(), -0.5)
+	assert.LessOrEqual(t, b.Tokens(), 3.0)
+
+	assert.False(t, b.ReserveN(4).OK())
+	assert.Equal(t, time.Duration(1<<63-1), b.ReserveN(4).Delay())
+
+	// rate 为0时只能使用初始的令牌
+	b = NewTokenBucket(0, 1)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Reserve().OK())
+	assert.ErrorIs(t, b.Wait(context.Background()), ErrLimitExhausted)
+}
+
+func TestTokenBucketCancel(t *testing.T) {
+	// 到达执行时间后取消不归还令牌
+	b := NewTokenBucket(100, 1)
+	assert.True(t, b.Allow())
+	r := b.Reserve()
+	assert.True(t, r.OK())
+	time.Sleep(15 * time.Millisecond)
+	r.Cancel()
+	assert.False(t, b.Allow())
+
+	// 之后的预留占用的令牌不归还
+	b = NewTokenBucket(100, 1)
+	assert.True(t, b.Allow())
+	r1 := b.Reserve()
+	r2 := b.Reserve()
+	r1.Cancel()
+	assert.Less(t, b.Tokens(), -1.5)
+	// 取消最后一个预留归还它占用的令牌
+	r2.Cancel()
+	assert.Greater(t, b.Tokens(), -1.1)
+}
+
+func TestLimiterArgs(t *testing.T) {
+	b := NewTokenBucket(100, 0)
+	assert.True(t, b.Allow())
+
+	l := NewSlidingWindowLimiter(-1, time.Second)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// window 小于等于0时不限制
+	l = NewSlidingWindowLimiter(1, 0)
+	for i := 0; i < 10; i++ {
+		assert.True(t, l.Allow())
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b := NewTokenBucket(50, 1)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, b.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 55*time.Millisecond)
+
+	assert.ErrorIs(t, b.WaitN(context.Background(), 2), ErrLimitExceedBurst)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Wait(ctx), ErrLimitDeadline)
+
+	// 取消等待后归还令牌
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(5*time.Millisecond, cancel)
+	assert.ErrorIs(t, b.Wait(ctx), context.Canceled)
+	assert.Greater(t, b.Tokens(), -0.5)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, b.Wait(ctx), context.Canceled)
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	l := NewSlidingWindowLimiter(3, 50*time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+	assert.False(t, l.AllowN(4))
+
+	r := l.Reserve()
+	assert.True(t, r.OK())
+	assert.Greater(t, r.Delay(), 30*time.Millisecond)
+	r.Cancel()
+	assert.False(t, l.Allow())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, l.AllowN(3))
+	assert.False(t, l.Allow())
+
+	start := time.Now()
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	assert.True(t, l.AllowN(2))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), ErrLimitDeadline)
+	assert.ErrorIs(t, l.WaitN(context.Background(), 4), ErrLimitExceedBurst)
+}
+
+func TestThrottle(t *testing.T) {
+	start := time.Now()
+	out := Throttle(context.Background(), Stream(context.Background(), 1, 2, 3, 4, 5), 100, 1)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, collect(out))
+	assert.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out = Throttle(ctx, Stream(context.Background(), 1, 2, 3), 1, 1)
+	assert.Equal(t, 1, <-out)
+	cancel()
+	assert.Empty(t, collect(out))
+
+	// ctx 的deadline早于下一个令牌时等待到ctx结束, 而不是立即关闭
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	out = Throttle(ctx, Stream(context.Background(), 1, 2), 1, 1)
+	assert.Equal(t, []int{1}, collect(out))
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+
+	// rate 小于等于0时不限制速率
+	out = Throttle(context.Background(), Stream(context.Background(), 1, 2, 3), 0, 1)
+	assert.Equal(t, []int{1, 2, 3}, collect(out))
+}
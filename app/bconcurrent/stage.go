@@ -0,0 +1,238 @@
+package bconcurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrFlowRunning = errors.New("bconcurrent: flow already run")
+
+// Stage 流水线中的一个阶段, 对每个输入调用Fn得到输出
+type Stage[In, Out any] struct {
+	// Name 阶段名称, 用于错误信息
+	Name string
+	// Fn 处理函数, 返回错误时取消整条流水线
+	Fn func(ctx context.Context, in In) (Out, error)
+	// Concurrency 并发数, 小于等于1时串行处理并保持顺序
+	Concurrency int
+	// Buffer 输出channel的缓冲大小
+	Buffer int
+}
+
+// NewStage 初始化阶段
+func NewStage[In, Out any](name string, concurrency int, fn func(ctx context.Context, in In) (Out, error)) Stage[In, Out] {
+	return Stage[In, Out]{
+		Name:        name,
+		Fn:          fn,
+		Concurrency: concurrency,
+	}
+}
+
+// StageError 阶段返回的错误
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("bconcurrent: stage %s: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Errors 多个阶段返回的错误
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is 任意一个错误匹配target时返回true
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 找到第一个匹配target的错误
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flow 由多个阶段组成的流水线, 先通过 Source Then Sink 构建, 再调用 Run 启动
+// 任意阶段返回错误时取消整条流水线, Run 在所有协程退出后返回汇总的错误
+type Flow struct {
+	runs []func(ctx context.Context) error
+	ran  int32
+}
+
+// NewFlow 初始化流水线
+func NewFlow() *Flow {
+	return &Flow{}
+}
+
+// Port 阶段之间的连接, 携带上一个阶段的输出
+type Port[T any] struct {
+	flow *Flow
+	ch   chan T
+}
+
+func (f *Flow) add(run func(ctx context.Context) error) {
+	f.runs = append(f.runs, run)
+}
+
+// Run 启动流水线并等待所有协程退出, 只能调用一次
+// 返回所有阶段的错误(不包括因取消产生的错误), 没有阶段出错但ctx结束时返回 ctx.Err()
+func (f *Flow) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&f.ran, 0, 1) {
+		return ErrFlowRunning
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		errs Errors
+		wg   sync.WaitGroup
+	)
+	for _, run := range f.runs {
+		run := run
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := run(ctx)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			cancel()
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) != 0 {
+		return errs
+	}
+	return ctx.Err()
+}
+
+// Source 流水线的数据源, fn 通过emit输出数据, emit 在流水线取消后返回 ctx.Err()
+func Source[T any](f *Flow, name string, buffer int, fn func(ctx context.Context, emit func(v T) error) error) *Port[T] {
+	out := &Port[T]{flow: f, ch: make(chan T, buffer)}
+	f.add(func(ctx context.Context) error {
+		defer close(out.ch)
+		emit := func(v T) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out.ch <- v:
+				return nil
+			}
+		}
+		err := call(func() error { return fn(ctx, emit) })
+		if err == nil || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+			return nil
+		}
+		return &StageError{Stage: name, Err: err}
+	})
+	return out
+}
+
+// FromSlice 依次输出values的数据源
+func FromSlice[T any](f *Flow, values ...T) *Port[T] {
+	return Source(f, "slice", 0, func(ctx context.Context, emit func(v T) error) error {
+		for _, v := range values {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Then 将in的数据交给stage处理, 返回stage的输出
+// 每个 Port 只能被下游消费一次, Concurrency 大于1时输出不保证顺序
+func Then[In, Out any](in *Port[In], stage Stage[In, Out]) *Port[Out] {
+	out := &Port[Out]{flow: in.flow, ch: make(chan Out, stage.Buffer)}
+	n := stage.Concurrency
+	if n < 1 {
+		n = 1
+	}
+	remaining := int32(n)
+	for i := 0; i < n; i++ {
+		in.flow.add(func(ctx context.Context) error {
+			defer func() {
+				// 最后一个退出的协程关闭输出
+				if atomic.AddInt32(&remaining, -1) == 0 {
+					close(out.ch)
+				}
+			}()
+			return consume(ctx, stage.Name, in.ch, func(v In) error {
+				var ret Out
+				err := call(func() (err error) {
+					ret, err = stage.Fn(ctx, v)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case out.ch <- ret:
+					return nil
+				}
+			})
+		})
+	}
+	return out
+}
+
+// Sink 流水线的终点, 依次对in的数据调用fn
+func Sink[T any](in *Port[T], name string, fn func(ctx context.Context, v T) error) {
+	in.flow.add(func(ctx context.Context) error {
+		return consume(ctx, name, in.ch, func(v T) error {
+			return call(func() error { return fn(ctx, v) })
+		})
+	})
+}
+
+// consume 从ch读取数据直到ch关闭或ctx结束, 因ctx结束产生的错误会被忽略
+func consume[T any](ctx context.Context, name string, ch <-chan T, fn func(v T) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case v, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := fn(v); err != nil {
+				if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+					return nil
+				}
+				return &StageError{Stage: name, Err: err}
+			}
+		}
+	}
+}
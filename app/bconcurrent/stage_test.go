@@ -0,0 +1,120 @@
+package bconcurrent
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlow(t *testing.T) {
+	f := NewFlow()
+	src := FromSlice(f, 1, 2, 3, 4, 5)
+	doubled := Then(src, NewStage("double", 3, func(ctx context.Context, v int) (int, error) {
+		return v * 2, nil
+	}))
+	str := Then(doubled, NewStage("itoa", 1, func(ctx context.Context, v int) (string, error) {
+		return strconv.Itoa(v), nil
+	}))
+	var ret []string
+	Sink(str, "collect", func(ctx context.Context, v string) error {
+		ret = append(ret, v)
+		return nil
+	})
+	assert.NoError(t, f.Run(context.Background()))
+	sort.Strings(ret)
+	assert.Equal(t, []string{"10", "2", "4", "6", "8"}, ret)
+
+	assert.ErrorIs(t, f.Run(context.Background()), ErrFlowRunning)
+}
+
+func TestFlowError(t *testing.T) {
+	before := runtime.NumGoroutine()
+	errStage := errors.New("stage failed")
+
+	var emitted int32
+	f := NewFlow()
+	src := Source(f, "counter", 0, func(ctx context.Context, emit func(v int) error) error {
+		// 无限输出, 只能依靠取消退出
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+			atomic.AddInt32(&emitted, 1)
+		}
+	})
+	mid := Then(src, NewStage("check", 4, func(ctx context.Context, v int) (int, error) {
+		if v == 10 {
+			return 0, errStage
+		}
+		return v, nil
+	}))
+	Sink(mid, "drop", func(ctx context.Context, v int) error { return nil })
+
+	err := f.Run(context.Background())
+	assert.ErrorIs(t, err, errStage)
+	var se *StageError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, "check", se.Stage)
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&emitted), int32(10))
+
+	// 所有协程都已退出
+	time.Sleep(10 * time.Millisecond)
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestFlowMultiError(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	release := make(chan struct{})
+
+	f := NewFlow()
+	src := FromSlice(f, 1, 2)
+	mid := Then(src, NewStage("fail", 2, func(ctx context.Context, v int) (int, error) {
+		// 两个协程都拿到数据后同时失败
+		if v == 1 {
+			<-release
+			return 0, errA
+		}
+		close(release)
+		return 0, errB
+	}))
+	Sink(mid, "drop", func(ctx context.Context, v int) error { return nil })
+
+	err := f.Run(context.Background())
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 2)
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+}
+
+func TestFlowPanic(t *testing.T) {
+	f := NewFlow()
+	src := FromSlice(f, 1)
+	Sink(src, "panic", func(ctx context.Context, v int) error {
+		panic("boom")
+	})
+	err := f.Run(context.Background())
+	var pe *PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, "boom", pe.Value)
+}
+
+func TestFlowContext(t *testing.T) {
+	f := NewFlow()
+	src := Source(f, "block", 0, func(ctx context.Context, emit func(v int) error) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	Sink(src, "drop", func(ctx context.Context, v int) error { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, f.Run(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,246 @@
+package bconcurrent
+
+import (
+	"context"
+	"time"
+)
+
+// Batch 将流中的数据按批输出, 数量达到maxSize或第一个数据等待超过maxWait时输出一批
+// maxSize 小于等于0时不限制数量, maxWait 小于等于0时不限制等待时间, 流关闭时输出剩余的数据
+func Batch[T any](ctx context.Context, valueStream <-chan T, maxSize int, maxWait time.Duration) <-chan []T {
+	outStream := make(chan []T)
+	go func() {
+		defer close(outStream)
+		var (
+			batch   []T
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		stop := func() {
+			if timer != nil && !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timeout = nil
+		}
+		defer stop()
+		flush := func() bool {
+			stop()
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case outStream <- batch:
+				batch = nil
+				return true
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timeout:
+				timeout = nil
+				if !flush() {
+					return
+				}
+			case v, ok := <-valueStream:
+				if !ok {
+					flush()
+					return
+				}
+				if batch == nil && maxSize > 0 {
+					batch = make([]T, 0, maxSize)
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					if timer == nil {
+						timer = time.NewTimer(maxWait)
+					} else {
+						timer.Reset(maxWait)
+					}
+					timeout = timer.C
+				}
+				if maxSize > 0 && len(batch) >= maxSize && !flush() {
+					return
+				}
+			}
+		}
+	}()
+	return outStream
+}
+
+// TumblingWindow 滚动时间窗口, 每隔size输出一次窗口内的数据, 窗口之间不重叠, 没有数据的窗口不输出
+// 与 Batch 相同, size 小于等于0时不按时间输出, 只在流关闭时输出全部数据, 流关闭时输出剩余的数据
+func TumblingWindow[T any](ctx context.Context, valueStream <-chan T, size time.Duration) <-chan []T {
+	outStream := make(chan []T)
+	go func() {
+		defer close(outStream)
+		var tick <-chan time.Time
+		if size > 0 {
+			ticker := time.NewTicker(size)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		var window []T
+		flush := func() bool {
+			if len(window) == 0 {
+				return true
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case outStream <- window:
+				window = nil
+				return true
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+				if !flush() {
+					return
+				}
+			case v, ok := <-valueStream:
+				if !ok {
+					flush()
+					return
+				}
+				window = append(window, v)
+			}
+		}
+	}()
+	return outStream
+}
+
+// timedValue 记录到达时间的数据
+type timedValue[T any] struct {
+	at time.Time
+	v  T
+}
+
+// SlidingWindow 滑动时间窗口, 每隔slide输出一次最近size内到达的数据, slide 小于size时相邻窗口会重叠
+// 没有数据的窗口不输出, 流关闭时如果有尚未输出过的数据则再输出一次当前窗口
+// 与 Batch 相同, 小于等于0的时间不生效: size 小于等于0时数据不会滑出窗口, slide 小于等于0时不按时间输出, 只在流关闭时输出
+func SlidingWindow[T any](ctx context.Context, valueStream <-chan T, size, slide time.Duration) <-chan []T {
+	outStream := make(chan []T)
+	go func() {
+		defer close(outStream)
+		var tick <-chan time.Time
+		if slide > 0 {
+			ticker := time.NewTicker(slide)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		var (
+			values []timedValue[T]
+			// dirty 上次输出后是否有新的数据
+			dirty bool
+		)
+		flush := func(now time.Time) bool {
+			// 移除已经滑出窗口的数据
+			i := 0
+			for size > 0 && i < len(values) && !values[i].at.After(now.Add(-size)) {
+				i++
+			}
+			values = append(values[:0], values[i:]...)
+			if len(values) == 0 {
+				return true
+			}
+			window := make([]T, len(values))
+			for i, tv := range values {
+				window[i] = tv.v
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case outStream <- window:
+				dirty = false
+				return true
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-tick:
+				if !flush(now) {
+					return
+				}
+			case v, ok := <-valueStream:
+				if !ok {
+					if dirty {
+						flush(time.Now())
+					}
+					return
+				}
+				values = append(values, timedValue[T]{at: time.Now(), v: v})
+				dirty = true
+			}
+		}
+	}()
+	return outStream
+}
+
+// CountWindow 计数窗口, 每到达slide个数据输出一次最近的size个数据
+// slide 小于等于0或等于size时为滚动窗口, 小于size时相邻窗口会重叠, 流关闭时如果有尚未输出过的数据则再输出一次当前窗口
+func CountWindow[T any](ctx context.Context, valueStream <-chan T, size, slide int) <-chan []T {
+	if size < 1 {
+		size = 1
+	}
+	if slide <= 0 {
+		slide = size
+	}
+	outStream := make(chan []T)
+	go func() {
+		defer close(outStream)
+		var (
+			values []T
+			// n 上次输出后到达的数据数量
+			n int
+		)
+		flush := func() bool {
+			window := make([]T, len(values))
+			copy(window, values)
+			select {
+			case <-ctx.Done():
+				return false
+			case outStream <- window:
+				n = 0
+				if slide >= size {
+					// 窗口之间不重叠
+					values = values[:0]
+				}
+				return true
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-valueStream:
+				if !ok {
+					if n > 0 {
+						flush()
+					}
+					return
+				}
+				values = append(values, v)
+				if len(values) > size {
+					values = append(values[:0], values[len(values)-size:]...)
+				}
+				n++
+				if n >= slide && !flush() {
+					return
+				}
+			}
+		}
+	}()
+	return outStream
+}
@@ -0,0 +1,124 @@
+package bconcurrent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect[T any](in <-chan T) []T {
+	var ret []T
+	for v := range in {
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+func TestBatch(t *testing.T) {
+	out := Batch(context.Background(), Stream(context.Background(), 1, 2, 3, 4, 5), 2, time.Second)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, collect(out))
+
+	// 等待超时后输出不满的一批
+	in := make(chan int)
+	out = Batch(context.Background(), in, 10, 10*time.Millisecond)
+	start := time.Now()
+	in <- 1
+	in <- 2
+	assert.Equal(t, []int{1, 2}, <-out)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	in <- 3
+	assert.Equal(t, []int{3}, <-out)
+	close(in)
+	_, ok := <-out
+	assert.False(t, ok)
+
+	// 只在关闭时输出
+	out = Batch(context.Background(), Stream(context.Background(), 1, 2, 3), 0, 0)
+	assert.Equal(t, [][]int{{1, 2, 3}}, collect(out))
+}
+
+func TestBatchContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := Batch(ctx, in, 10, time.Hour)
+	in <- 1
+	cancel()
+	assert.Empty(t, collect(out))
+}
+
+func TestTumblingWindow(t *testing.T) {
+	in := make(chan int)
+	out := TumblingWindow(context.Background(), in, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	assert.Equal(t, []int{1, 2}, <-out)
+	in <- 3
+	assert.Equal(t, []int{3}, <-out)
+	in <- 4
+	close(in)
+	assert.Equal(t, [][]int{{4}}, collect(out))
+
+	// size 小于等于0时只在关闭时输出
+	out = TumblingWindow(context.Background(), Stream(context.Background(), 1, 2, 3), 0)
+	assert.Equal(t, [][]int{{1, 2, 3}}, collect(out))
+}
+
+func TestSlidingWindow(t *testing.T) {
+	in := make(chan int)
+	out := SlidingWindow(context.Background(), in, 50*time.Millisecond, 10*time.Millisecond)
+	in <- 1
+	in <- 2
+	var w []int
+	for w = range out {
+		if len(w) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []int{1, 2}, w)
+	in <- 3
+	for w = range out {
+		if len(w) == 1 {
+			break
+		}
+	}
+	// 1 2 滑出窗口后只剩3
+	assert.Equal(t, []int{3}, w)
+	in <- 4
+	close(in)
+	ret := collect(out)
+	assert.NotEmpty(t, ret)
+	assert.Contains(t, ret[len(ret)-1], 4)
+
+	// slide 小于等于0时只在关闭时输出
+	out = SlidingWindow(context.Background(), Stream(context.Background(), 1, 2, 3), time.Hour, 0)
+	assert.Equal(t, [][]int{{1, 2, 3}}, collect(out))
+	// size 小于等于0时数据不会滑出窗口
+	in = make(chan int)
+	out = SlidingWindow(context.Background(), in, -1, 10*time.Millisecond)
+	in <- 1
+	assert.Equal(t, []int{1}, <-out)
+	// 窗口在输出时不接收数据, 在单独的协程中写入
+	go func() { in <- 2 }()
+	for w = range out {
+		if len(w) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []int{1, 2}, w)
+	close(in)
+	collect(out)
+}
+
+func TestCountWindow(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	out := CountWindow(context.Background(), Stream(context.Background(), values...), 2, 0)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, collect(out))
+
+	out = CountWindow(context.Background(), Stream(context.Background(), values...), 3, 1)
+	assert.Equal(t, [][]int{{1}, {1, 2}, {1, 2, 3}, {2, 3, 4}, {3, 4, 5}}, collect(out))
+
+	out = CountWindow(context.Background(), Stream(context.Background(), values...), 3, 2)
+	assert.Equal(t, [][]int{{1, 2}, {2, 3, 4}, {3, 4, 5}}, collect(out))
+}
@@ -0,0 +1,70 @@
+// Package keyhash 提供 comparable 类型key的默认hash函数, 用于分片以及分区
+package keyhash
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/songzhibin97/go-baseutils/internal/wyhash"
+)
+
+// Default 默认的hash函数, 字符串与数值类型直接计算, 其他类型按照 fmt 格式化后的字符串计算
+// 格式化每次都会分配内存, 结构体等类型的key在热点路径上建议使用专用的hash函数
+func Default[K comparable]() func(k K) uint64 {
+	return func(k K) uint64 {
+		switch v := any(k).(type) {
+		case string:
+			return wyhash.Sum64String(v)
+		case int:
+			return mix64(uint64(v))
+		case int8:
+			return mix64(uint64(v))
+		case int16:
+			return mix64(uint64(v))
+		case int32:
+			return mix64(uint64(v))
+		case int64:
+			return mix64(uint64(v))
+		case uint:
+			return mix64(uint64(v))
+		case uint8:
+			return mix64(uint64(v))
+		case uint16:
+			return mix64(uint64(v))
+		case uint32:
+			return mix64(uint64(v))
+		case uint64:
+			return mix64(v)
+		case uintptr:
+			return mix64(uint64(v))
+		case float32:
+			return mix64(floatBits(float64(v)))
+		case float64:
+			return mix64(floatBits(v))
+		default:
+			return wyhash.Sum64String(fmt.Sprintf("%#v", k))
+		}
+	}
+}
+
+// floatBits 返回浮点数用于hash的位, 与map的key语义保持一致
+// 0 与 -0 是同一个key, 所有 NaN 归为同一个值(NaN 作为key时永远无法命中)
+func floatBits(f float64) uint64 {
+	switch {
+	case f == 0:
+		return 0
+	case f != f:
+		return math.Float64bits(math.NaN())
+	}
+	return math.Float64bits(f)
+}
+
+// mix64 打散整数的分布
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
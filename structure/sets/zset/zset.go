@@ -446,12 +446,12 @@ func (z *Set[K]) RemoveRangeByRank(start, stop int) []Node[K] {
 //
 // RemoveRangeByScore is the replacement of ZREMRANGEBYSCORE command of redis.
 func (z *Set[K]) RemoveRangeByScore(min, max float64) []Node[K] {
-	return z.RevRangeByScoreWithOpt(min, max, RangeOpt{})
+	return z.RemoveRangeByScoreWithOpt(min, max, RangeOpt{})
 }
 
 func (z *Set[K]) RemoveRangeByScoreWithOpt(min, max float64, opt RangeOpt) []Node[K] {
-	z.mu.RLock()
-	defer z.mu.RUnlock()
+	z.mu.Lock()
+	defer z.mu.Unlock()
 
 	return z.list.DeleteRangeByScore(min, max, opt, z.dict)
 }
@@ -514,6 +514,19 @@ func testFloat64SetRemoveRangeByScoreWithOpt(t *testing.T, opt RangeOpt) {
 	assert.Equal(t, N, z.Len()+len(actualNs))
 }
 
+func TestSetRemoveRangeByScore(t *testing.T) {
+	z := New[string](bcomparator.StringComparator())
+	for i := 0; i < 10; i++ {
+		z.AddB(float64(i), fmt.Sprint(i))
+	}
+	ns := z.RemoveRangeByScore(0, 4)
+	assert.Len(t, ns, 5)
+	for _, n := range ns {
+		assert.False(t, z.Contains(n.Value))
+	}
+	assert.Equal(t, 5, z.Len())
+}
+
 func TestUnionFloat64(t *testing.T) {
 	var zs []*Set[string]
 	for i := 0; i < 10; i++ {